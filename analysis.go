@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/tools/go/types"
+)
+
+// The result of walking a loaded program for function usages.
+type analysis struct {
+	prog *ssa.Program
+
+	// usage counts keyed by function name, standard library functions are
+	// only included if -std is set
+	calls map[string]int
+
+	// every function in the program keyed by name
+	fnNames map[string]*ssa.Function
+
+	// the visitor holding the sites of every usage
+	v *visitor
+}
+
+// Build the SSA for a loaded program and count the function usages within
+// the packages matching usages.
+func analyze(program *loader.Program, usages *regexp.Regexp) (*analysis, error) {
+	prog := ssa.Create(program, 0)
+	prog.BuildAll()
+
+	calls := map[string]int{}
+	// create a map of names to function values to use later
+	fnNames := map[string]*ssa.Function{}
+
+	// AllFunctions list all functions reachable by this set of programs.
+	funcs := ssautil.AllFunctions(prog)
+	pkgs := prog.AllPackages()
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages specified")
+	}
+
+	for fn := range funcs {
+
+		name := funcName(fn)
+		// prefer the declared function over a synthetic wrapper of it
+		if prev, ok := fnNames[name]; !ok || prev.Synthetic != "" {
+			fnNames[name] = fn
+		}
+		if includeStdPkgs || !inStandardPackages(fn) {
+			calls[name] = 0
+		}
+	}
+
+	// the visitor will track function usages as it walks the ssa tree
+	v := newVisitor(calls)
+
+	for _, pkg := range pkgs {
+		pkgPath := pkg.Object.Path()
+		if !usages.MatchString(pkgPath) {
+			continue
+		}
+
+		// given a top level function, walk it looking for function usages
+		walkFunc := func(fn *ssa.Function) {
+			if fn.Pkg.Object.Path() != pkgPath {
+				return
+			}
+			for _, block := range fn.Blocks {
+				for i := range block.Instrs {
+					v.walkInstr(block.Instrs[i])
+				}
+			}
+			if fn.Recover != nil {
+				for i := range fn.Recover.Instrs {
+					v.walkInstr(fn.Recover.Instrs[i])
+				}
+			}
+			for i := range fn.AnonFuncs {
+				v.walkValue(fn.AnonFuncs[i])
+			}
+			return
+		}
+
+		for _, mem := range pkg.Members {
+			switch mem := mem.(type) {
+			case *ssa.Function:
+				walkFunc(mem)
+			case *ssa.Type:
+				// if the member is a *ssa.Type walk all methods on that type
+				namedType, ok := mem.Type().(*types.Named)
+				if !ok {
+					panic("global type is not a named type!")
+				}
+				for i := 0; i < namedType.NumMethods(); i++ {
+					fn := prog.FuncValue(namedType.Method(i))
+					walkFunc(fn)
+				}
+			case *ssa.Global:
+			}
+		}
+	}
+	return &analysis{prog, calls, fnNames, v}, nil
+}
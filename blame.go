@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Who last changed a declaration and when, found with git blame.
+type blame struct {
+	name   string
+	hash   string
+	author string
+	time   time.Time
+
+	// the last commit since the declaration was changed which added or
+	// removed a line naming the function, as a whole word, in its
+	// package's directory, which is likely when its last caller was removed
+	removedHash string
+	removedTime time.Time
+}
+
+type byBlameTime []blame
+
+func (a byBlameTime) Len() int      { return len(a) }
+func (a byBlameTime) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byBlameTime) Less(i, j int) bool {
+	if !a[i].time.Equal(a[j].time) {
+		return a[i].time.Before(a[j].time)
+	}
+	return a[i].name < a[j].name
+}
+
+// Blame a range of lines, returning the most recent commit to change any of
+// them.
+func blameLines(filename string, start, end int) (hash, author string, t time.Time, err error) {
+	out, err := git(filepath.Dir(filename), "blame", "--line-porcelain",
+		"-L", fmt.Sprintf("%d,%d", start, end), "--", filepath.Base(filename))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	var cur, curAuthor, curMail string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		switch {
+		case len(fields[0]) == 40 && len(fields) == 2:
+			cur = fields[0]
+		case fields[0] == "author" && len(fields) == 2:
+			curAuthor = fields[1]
+		case fields[0] == "author-mail" && len(fields) == 2:
+			curMail = fields[1]
+		case fields[0] == "author-time" && len(fields) == 2:
+			sec, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return "", "", time.Time{}, err
+			}
+			if lt := time.Unix(sec, 0).UTC(); hash == "" || lt.After(t) {
+				hash, author, t = cur, curAuthor+" "+curMail, lt
+			}
+		}
+	}
+	if hash == "" {
+		return "", "", time.Time{}, fmt.Errorf("no blame for %s:%d,%d", filename, start, end)
+	}
+	return hash, author, t, nil
+}
+
+// Find the last commit since a given one which added or removed a line
+// containing an identifier, as a whole word, within a directory.
+func lastOccurrenceChange(dir, since, ident string) (string, time.Time, bool) {
+	word := "(^|[^A-Za-z0-9_])" + regexp.QuoteMeta(ident) + "([^A-Za-z0-9_]|$)"
+	out, err := git(dir, "log", "-1", "--format=%H %ct", "-G"+word, since+"..HEAD", "--", ".")
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return "", time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return fields[0], time.Unix(sec, 0).UTC(), true
+}
+
+// Print the functions with no usages along with the commit and author which
+// last changed their declaration, oldest first. If a later commit added or
+// removed a line naming the function in its package's directory, that is
+// reported as when its last caller was removed. Functions outside of a git
+// repository are listed without blame.
+func printBlame(fset *token.FileSet, decls map[token.Pos]*ast.FuncDecl, calls map[string]int, fnNames map[string]*ssa.Function, shouldPrint func(name string) bool) {
+	blamed := []blame{}
+	unknown := []string{}
+	for name, n := range calls {
+		fn := fnNames[name]
+		if n != 0 || strings.Contains(name, "$") || isRoot(fn) || !shouldPrint(name) {
+			continue
+		}
+		fd, ok := funcDecl(decls, fn)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		start, end := fset.Position(fd.Pos()), fset.Position(fd.End())
+		if fd.Doc != nil {
+			start = fset.Position(fd.Doc.Pos())
+		}
+		hash, author, t, err := blameLines(start.Filename, start.Line, end.Line)
+		if err != nil {
+			unknown = append(unknown, name)
+			continue
+		}
+		b := blame{name: name, hash: hash, author: author, time: t}
+		removed, rt, ok := lastOccurrenceChange(filepath.Dir(start.Filename), hash, fn.Name())
+		if ok {
+			b.removedHash, b.removedTime = removed, rt
+		}
+		blamed = append(blamed, b)
+	}
+	sort.Sort(byBlameTime(blamed))
+	sort.Strings(unknown)
+
+	for _, b := range blamed {
+		removed := "-"
+		if b.removedHash != "" {
+			removed = fmt.Sprintf("%s %.8s", b.removedTime.Format("2006-01-02"), b.removedHash)
+		}
+		fmt.Printf("%s %.8s %s caller-removed: %s %s\n",
+			b.time.Format("2006-01-02"), b.hash, b.name, removed, b.author)
+	}
+	for _, name := range unknown {
+		fmt.Printf("%-19s %s\n", "-", name)
+	}
+}
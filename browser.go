@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The number of functions listed per page.
+const browserPage = 20
+
+const browserHelp = `commands:
+  list, l          list the current page of functions
+  next, n          list the next page
+  prev, p          list the previous page
+  pkg [regexp]     only list functions of matching packages, all if empty
+  sort count|name  sort by usage count, highest first, or by name
+  scope [regexp]   set the scope, the scope given on the command line if empty
+  std              toggle listing functions from standard packages
+  show <name>      show a function's callers, call sites and callees
+  <number>         show the function numbered in the last list or function
+  back, b          show the previously shown function
+  help, h          print this help
+  quit, q          exit
+`
+
+// An interactive browser of an analysis. The program is loaded and analysed
+// once, filters and sorting only change what is listed.
+type browser struct {
+	a    *analysis
+	fset *token.FileSet
+
+	defaultScope *regexp.Regexp
+	scope        *regexp.Regexp
+	// packages to list, all if nil
+	pkg     *regexp.Regexp
+	std     bool
+	byCount bool
+
+	// the call graph, including standard functions if std is set
+	graph graph
+
+	// the filtered and sorted functions and the page of them being listed
+	list []string
+	page int
+
+	// what a number typed at the prompt refers to
+	links []string
+	// the functions shown, most recent last
+	shown []string
+}
+
+func newBrowser(a *analysis, fset *token.FileSet, scope *regexp.Regexp) *browser {
+	b := &browser{a: a, fset: fset, defaultScope: scope, scope: scope, std: includeStdPkgs}
+	b.update()
+	return b
+}
+
+// The usage count of a function. Standard functions are counted from their
+// separately tracked sites unless -std was given.
+func (b *browser) count(name string) int {
+	if n, ok := b.a.calls[name]; ok {
+		return n
+	}
+	return len(b.a.v.stdSites[name])
+}
+
+func (b *browser) sites(name string) []site {
+	if s, ok := b.a.v.sites[name]; ok {
+		return s
+	}
+	return b.a.v.stdSites[name]
+}
+
+// Should a function be listed? Standard functions are listed when std is
+// set, other functions when they're in scope.
+func (b *browser) listed(name string) bool {
+	fn := b.a.fnNames[name]
+	if strings.Contains(name, "$") || dirs.ignored(name) {
+		return false
+	}
+	if b.pkg != nil && !b.pkg.MatchString(funcPackage(fn)) {
+		return false
+	}
+	if inStandardPackages(fn) {
+		return b.std
+	}
+	return packageMatches(b.scope, fn)
+}
+
+type byCountThenName struct {
+	names []string
+	count func(name string) int
+}
+
+func (a byCountThenName) Len() int      { return len(a.names) }
+func (a byCountThenName) Swap(i, j int) { a.names[i], a.names[j] = a.names[j], a.names[i] }
+func (a byCountThenName) Less(i, j int) bool {
+	m, n := a.count(a.names[i]), a.count(a.names[j])
+	if m != n {
+		return m > n
+	}
+	return a.names[i] < a.names[j]
+}
+
+// Recompute the call graph and the list of functions after a filter changed.
+func (b *browser) update() {
+	b.graph = callGraph(b.a.v.sites)
+	if b.std {
+		for callee, s := range b.a.v.stdSites {
+			for _, site := range s {
+				b.graph.addEdge(funcName(site.caller), callee)
+			}
+		}
+	}
+	b.list = []string{}
+	for name := range b.a.fnNames {
+		if b.listed(name) {
+			b.list = append(b.list, name)
+		}
+	}
+	if b.byCount {
+		sort.Sort(byCountThenName{b.list, b.count})
+	} else {
+		sort.Strings(b.list)
+	}
+	b.page = 0
+}
+
+// Print the current page of functions.
+func (b *browser) printList(w io.Writer) {
+	if len(b.list) == 0 {
+		b.links = nil
+		fmt.Fprintln(w, "no functions")
+		return
+	}
+	pages := (len(b.list) + browserPage - 1) / browserPage
+	if b.page >= pages {
+		b.page = pages - 1
+	}
+	start := b.page * browserPage
+	end := start + browserPage
+	if end > len(b.list) {
+		end = len(b.list)
+	}
+	b.links = b.list[start:end]
+	for i, name := range b.links {
+		fmt.Fprintf(w, "%4d %03d %s\n", i+1, b.count(name), name)
+	}
+	fmt.Fprintf(w, "page %d of %d, %d functions\n", b.page+1, pages, len(b.list))
+}
+
+// Print a function's callers with their call sites, and its callees.
+// Callers and callees are numbered so they can be jumped to.
+func (b *browser) show(w io.Writer, name string) {
+	fn, ok := b.a.fnNames[name]
+	if !ok {
+		fmt.Fprintf(w, "no function %s\n", name)
+		return
+	}
+	if len(b.shown) == 0 || b.shown[len(b.shown)-1] != name {
+		b.shown = append(b.shown, name)
+	}
+	b.links = []string{}
+	fmt.Fprintf(w, "%s\n", name)
+	if fn.Pos().IsValid() {
+		fmt.Fprintf(w, "  declared at %s\n", b.fset.Position(fn.Pos()))
+	}
+	fmt.Fprintf(w, "  %d usages\n", b.count(name))
+
+	sites := []positioned{}
+	for _, s := range b.sites(name) {
+		sites = append(sites, positioned{instrPosition(b.fset, s.instr), funcName(s.caller)})
+	}
+	sort.Sort(byPosition(sites))
+	fmt.Fprintln(w, "callers:")
+	for _, s := range sites {
+		b.links = append(b.links, s.text)
+		fmt.Fprintf(w, "%4d %s %s\n", len(b.links), s.text, s.pos)
+	}
+	fmt.Fprintln(w, "callees:")
+	for _, callee := range b.graph.succs(name) {
+		b.links = append(b.links, callee)
+		fmt.Fprintf(w, "%4d %03d %s\n", len(b.links), b.count(callee), callee)
+	}
+}
+
+// Run a single command, returning false once the browser should exit.
+func (b *browser) command(w io.Writer, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+	arg := strings.Join(fields[1:], " ")
+	if i, err := strconv.Atoi(fields[0]); err == nil {
+		if i < 1 || i > len(b.links) {
+			fmt.Fprintf(w, "no function numbered %d\n", i)
+			return true
+		}
+		b.show(w, b.links[i-1])
+		return true
+	}
+	switch fields[0] {
+	case "list", "l":
+		b.printList(w)
+	case "next", "n":
+		b.page++
+		b.printList(w)
+	case "prev", "p":
+		if b.page > 0 {
+			b.page--
+		}
+		b.printList(w)
+	case "pkg":
+		b.pkg = nil
+		if arg != "" {
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				fmt.Fprintf(w, "bad regexp: %v\n", err)
+				return true
+			}
+			b.pkg = re
+		}
+		b.update()
+		b.printList(w)
+	case "sort":
+		switch arg {
+		case "count":
+			b.byCount = true
+		case "name":
+			b.byCount = false
+		default:
+			fmt.Fprintln(w, "sort by count or name")
+			return true
+		}
+		b.update()
+		b.printList(w)
+	case "scope":
+		b.scope = b.defaultScope
+		if arg != "" {
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				fmt.Fprintf(w, "bad regexp: %v\n", err)
+				return true
+			}
+			b.scope = re
+		}
+		b.update()
+		b.printList(w)
+	case "std":
+		b.std = !b.std
+		b.update()
+		b.printList(w)
+	case "show":
+		b.show(w, arg)
+	case "back", "b":
+		if len(b.shown) < 2 {
+			fmt.Fprintln(w, "nothing to go back to")
+			return true
+		}
+		b.shown = b.shown[:len(b.shown)-1]
+		b.show(w, b.shown[len(b.shown)-1])
+	case "help", "h":
+		fmt.Fprint(w, browserHelp)
+	case "quit", "q", "exit":
+		return false
+	default:
+		fmt.Fprintf(w, "unknown command %q, try help\n", fields[0])
+	}
+	return true
+}
+
+// Read and run commands until quit or the end of input.
+func (b *browser) run(r io.Reader, w io.Writer) {
+	b.printList(w)
+	in := bufio.NewScanner(r)
+	for {
+		fmt.Fprint(w, "> ")
+		if !in.Scan() {
+			fmt.Fprintln(w)
+			return
+		}
+		if !b.command(w, in.Text()) {
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// The name of the project configuration file, which is discovered by
+// searching from the working directory upward.
+const configFilename = ".giveupthefunc.json"
+
+// A project configuration file, checked in alongside the code it describes.
+// Flags given on the command line override its values.
+type projectConfig struct {
+	// import paths to analyse when none are given as arguments
+	Packages []string `json:"packages,omitempty"`
+
+	Usages  string `json:"usages,omitempty"`
+	Scope   string `json:"scope,omitempty"`
+	Std     bool   `json:"std,omitempty"`
+	Public  string `json:"public,omitempty"`
+	Library string `json:"library,omitempty"`
+
+	// regexps matched against function names, matching functions are roots
+	Roots []string `json:"roots,omitempty"`
+
+	// regexps matched against function names, matching functions are hidden
+	// from reports
+	Ignore []string `json:"ignore,omitempty"`
+
+	// policy rules, either inline or in a separate file relative to the
+	// configuration file
+	Policy     *policy `json:"policy,omitempty"`
+	PolicyFile string  `json:"policyFile,omitempty"`
+
+	Format string `json:"format,omitempty"`
+
+	// the compiled Roots and Ignore patterns
+	rootMatchers   []*regexp.Regexp
+	ignoreMatchers []*regexp.Regexp
+}
+
+// Functions matching the roots and ignore patterns of the configuration.
+var (
+	rootMatchers   []*regexp.Regexp
+	ignoreMatchers []*regexp.Regexp
+)
+
+// Search for a configuration file from dir upward.
+func findConfig(dir string) (string, bool) {
+	for {
+		filename := filepath.Join(dir, configFilename)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Read a configuration file. If the file refers to a separate policy file,
+// the policy is read from it.
+func loadConfig(filename string) (*projectConfig, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := &projectConfig{}
+	if err := json.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", filename, err)
+	}
+	if cfg.rootMatchers, err = compileAll(cfg.Roots); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if cfg.ignoreMatchers, err = compileAll(cfg.Ignore); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if cfg.Policy != nil {
+		if err := cfg.Policy.compile(); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	if cfg.PolicyFile != "" {
+		if !filepath.IsAbs(cfg.PolicyFile) {
+			cfg.PolicyFile = filepath.Join(filepath.Dir(filename), cfg.PolicyFile)
+		}
+		if cfg.Policy, err = loadPolicy(cfg.PolicyFile); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// Print the configuration as JSON.
+func printConfig(cfg *projectConfig) {
+	enc, err := json.MarshalIndent(cfg, "", "    ")
+	if err != nil {
+		fatalf("error encoding config: %v", err)
+	}
+	os.Stdout.Write(append(enc, '\n'))
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigBadPattern(t *testing.T) {
+	dir, err := ioutil.TempDir("", "giveupthefunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, configFilename)
+	if err := ioutil.WriteFile(filename, []byte(`{"roots": ["main$"], "ignore": ["(bad"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = loadConfig(filename)
+	if err == nil {
+		t.Fatal("expected an error for a bad ignore pattern")
+	}
+	if want := filename + ": (bad: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("expected an error starting %q, got %q", want, err)
+	}
+}
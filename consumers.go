@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"go/parser"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// A flag which may be repeated or given a comma separated list.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// Add every package in a source tree to a loader config, returning regexps
+// matching the added packages. Packages inside of GOPATH are imported by
+// their import path, other packages are created from their files. Hidden
+// directories, directories starting with '_' and testdata are skipped, as
+// the go tool does.
+func addSourceTree(config *loader.Config, root string) (*regexp.Regexp, error) {
+	paths := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata") {
+			return filepath.SkipDir
+		}
+		bp, err := build.ImportDir(path, 0)
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); ok {
+				return nil
+			}
+			return err
+		}
+		if bp.ImportPath != "" && bp.ImportPath != "." {
+			config.Import(bp.ImportPath)
+			paths = append(paths, bp.ImportPath)
+			return nil
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		files := []string{}
+		for _, f := range append(bp.GoFiles, bp.CgoFiles...) {
+			files = append(files, filepath.Join(abs, f))
+		}
+		config.CreateFromFilenames(abs, files...)
+		paths = append(paths, abs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no packages found in %s", root)
+	}
+	for i := range paths {
+		paths[i] = regexp.QuoteMeta(paths[i])
+	}
+	return regexp.Compile("^(" + strings.Join(paths, "|") + ")$")
+}
+
+// Count how often each consumer source tree uses the exported API of the
+// library packages. Each consumer is loaded and analysed as its own program.
+// Library functions used by no consumer are listed separately.
+func printConsumerUsage(libraries, consumers []string) {
+	config := &loader.Config{ParserMode: parser.ParseComments}
+	for _, lib := range libraries {
+		config.Import(lib)
+	}
+	program, err := config.Load()
+	if err != nil {
+		fatalf("error loading: %v", err)
+	}
+	libProg, err := analyze(program, regexp.MustCompile("^$"))
+	if err != nil {
+		fatalf("error analyzing: %v", err)
+	}
+	api := []string{}
+	for _, lib := range libraries {
+		pkg := libProg.prog.ImportedPackage(lib)
+		if pkg == nil {
+			fatalf("library %s was not loaded", lib)
+		}
+		api = append(api, exportedAPI(libProg.prog, pkg)...)
+	}
+	sort.Strings(api)
+
+	counts := map[string]int{}
+	usedBy := map[string][]string{}
+	for _, consumer := range consumers {
+		config := &loader.Config{ParserMode: parser.ParseComments}
+		matcher, err := addSourceTree(config, consumer)
+		if err != nil {
+			fatalf("error finding packages in %s: %v", consumer, err)
+		}
+		program, err := config.Load()
+		if err != nil {
+			fatalf("error loading %s: %v", consumer, err)
+		}
+		a, err := analyze(program, matcher)
+		if err != nil {
+			fatalf("error analyzing %s: %v", consumer, err)
+		}
+		for _, name := range api {
+			if n := a.calls[name]; n > 0 {
+				counts[name] += n
+				usedBy[name] = append(usedBy[name], consumer)
+			}
+		}
+	}
+
+	fmt.Println("USAGE (count, consumers):")
+	unused := []string{}
+	for _, name := range api {
+		if counts[name] == 0 {
+			unused = append(unused, name)
+			continue
+		}
+		fmt.Printf("%03d %03d %s %s\n", counts[name], len(usedBy[name]), name, strings.Join(usedBy[name], ","))
+	}
+	fmt.Println("UNUSED BY ANY CONSUMER:")
+	for _, name := range unused {
+		fmt.Println(name)
+	}
+}
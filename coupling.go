@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// The package a function belongs to. Methods of struct compositions are
+// attributed to the first package of their receiver.
+func funcPackage(fn *ssa.Function) string {
+	pkgs := funcPackages(fn)
+	if len(pkgs) == 0 {
+		return ""
+	}
+	return pkgs[0]
+}
+
+// Instability is Ce/(Ca+Ce), zero for isolated nodes.
+func instability(afferent, efferent int) float64 {
+	if afferent+efferent == 0 {
+		return 0
+	}
+	return float64(efferent) / float64(afferent+efferent)
+}
+
+type funcCoupling struct {
+	Name              string  `json:"name"`
+	FanIn             int     `json:"fanIn"`
+	FanOut            int     `json:"fanOut"`
+	CrossPackageFanIn int     `json:"crossPackageFanIn"`
+	Instability       float64 `json:"instability"`
+}
+
+type pkgCoupling struct {
+	Path        string  `json:"path"`
+	Afferent    int     `json:"afferent"`
+	Efferent    int     `json:"efferent"`
+	Instability float64 `json:"instability"`
+}
+
+type coupling struct {
+	Functions []funcCoupling `json:"functions"`
+	Packages  []pkgCoupling  `json:"packages"`
+}
+
+// Compute fan-in and fan-out for every in-scope function, counting distinct
+// callers and callees rather than references, and afferent and efferent
+// coupling for every in-scope package, counting distinct other packages.
+func computeCoupling(fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool) coupling {
+	g := callGraph(sites)
+
+	pkgOf := func(name string) string {
+		if fn, ok := fnNames[name]; ok {
+			return funcPackage(fn)
+		}
+		return ""
+	}
+
+	callers := map[string]map[string]bool{}
+	pkgIn := map[string]map[string]bool{}
+	pkgOut := map[string]map[string]bool{}
+	for from, edges := range g {
+		fromPkg := pkgOf(from)
+		for to := range edges {
+			if callers[to] == nil {
+				callers[to] = map[string]bool{}
+			}
+			callers[to][from] = true
+
+			toPkg := pkgOf(to)
+			if fromPkg == toPkg {
+				continue
+			}
+			if pkgIn[toPkg] == nil {
+				pkgIn[toPkg] = map[string]bool{}
+			}
+			pkgIn[toPkg][fromPkg] = true
+			if pkgOut[fromPkg] == nil {
+				pkgOut[fromPkg] = map[string]bool{}
+			}
+			pkgOut[fromPkg][toPkg] = true
+		}
+	}
+
+	c := coupling{Functions: []funcCoupling{}, Packages: []pkgCoupling{}}
+	pkgs := map[string]bool{}
+	for name := range fnNames {
+		if strings.Contains(name, "$") || !shouldPrint(name) {
+			continue
+		}
+		f := funcCoupling{Name: name, FanIn: len(callers[name]), FanOut: len(g[name])}
+		for caller := range callers[name] {
+			if pkgOf(caller) != pkgOf(name) {
+				f.CrossPackageFanIn++
+			}
+		}
+		f.Instability = instability(f.FanIn, f.FanOut)
+		c.Functions = append(c.Functions, f)
+		pkgs[pkgOf(name)] = true
+	}
+	for path := range pkgs {
+		p := pkgCoupling{Path: path, Afferent: len(pkgIn[path]), Efferent: len(pkgOut[path])}
+		p.Instability = instability(p.Afferent, p.Efferent)
+		c.Packages = append(c.Packages, p)
+	}
+	sort.Sort(byFanIn(c.Functions))
+	sort.Sort(byAfferent(c.Packages))
+	return c
+}
+
+type byFanIn []funcCoupling
+
+func (a byFanIn) Len() int      { return len(a) }
+func (a byFanIn) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byFanIn) Less(i, j int) bool {
+	if a[i].FanIn != a[j].FanIn {
+		return a[i].FanIn < a[j].FanIn
+	}
+	return a[i].Name < a[j].Name
+}
+
+type byAfferent []pkgCoupling
+
+func (a byAfferent) Len() int      { return len(a) }
+func (a byAfferent) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byAfferent) Less(i, j int) bool {
+	if a[i].Afferent != a[j].Afferent {
+		return a[i].Afferent < a[j].Afferent
+	}
+	return a[i].Path < a[j].Path
+}
+
+// Print coupling metrics as text, ordered by fan-in, or as JSON.
+func printCoupling(c coupling, format string) {
+	if format == "json" {
+		enc, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			fatalf("error encoding json: %v", err)
+		}
+		os.Stdout.Write(append(enc, '\n'))
+		return
+	}
+
+	fmt.Println("FUNCTIONS (fan-in, fan-out, cross package fan-in, instability):")
+	for _, f := range c.Functions {
+		fmt.Printf("%03d %03d %03d %.2f %s\n", f.FanIn, f.FanOut, f.CrossPackageFanIn, f.Instability, f.Name)
+	}
+	fmt.Println("PACKAGES (afferent, efferent, instability):")
+	for _, p := range c.Packages {
+		fmt.Printf("%03d %03d %.2f %s\n", p.Afferent, p.Efferent, p.Instability, p.Path)
+	}
+}
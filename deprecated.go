@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Print every use of a function whose doc comment marks it as deprecated,
+// whether it is declared in our packages or a dependency. Deprecated
+// functions are summarised with their number of uses, most used last,
+// followed by the position of every use.
+func printDeprecated(fset *token.FileSet, decls map[token.Pos]*ast.FuncDecl, fnNames map[string]*ssa.Function, sites, stdSites map[string][]site) {
+	summary := []string{}
+	uses := []positioned{}
+	for name, fn := range fnNames {
+		fd, ok := funcDecl(decls, fn)
+		if !ok || dirs.ignored(name) {
+			continue
+		}
+		msg, ok := deprecation(fd.Doc)
+		if !ok {
+			continue
+		}
+		// standard library functions are only in sites when -std is set
+		all := sites[name]
+		if len(all) == 0 {
+			all = stdSites[name]
+		}
+		s := []site{}
+		for _, site := range all {
+			if !dirs.ignoredSite(site) {
+				s = append(s, site)
+			}
+		}
+		if len(s) == 0 {
+			continue
+		}
+		summary = append(summary, fmt.Sprintf("%03d %s: %s", len(s), name, msg))
+		for _, site := range s {
+			uses = append(uses, positioned{instrPosition(fset, site.instr), name})
+		}
+	}
+	sort.Strings(summary)
+	sort.Sort(byPosition(uses))
+
+	fmt.Println("DEPRECATED:")
+	for i := range summary {
+		fmt.Println(summary[i])
+	}
+	fmt.Println("SITES:")
+	for _, use := range uses {
+		fmt.Printf("%s: %s\n", use.pos, use.text)
+	}
+}
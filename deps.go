@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// Guess the repository root of an import path. Paths starting with a host
+// such as github.com are rooted at their first three elements, gopkg.in paths
+// at their first two and paths without a host at their first.
+func repoRoot(path string) string {
+	elems := strings.Split(path, "/")
+	n := 3
+	switch {
+	case elems[0] == "gopkg.in":
+		n = 2
+	case !strings.Contains(elems[0], "."):
+		n = 1
+	}
+	if len(elems) < n {
+		n = len(elems)
+	}
+	return strings.Join(elems[:n], "/")
+}
+
+// List the names of the exported functions of a package and the exported
+// methods of its exported types.
+func exportedAPI(prog *ssa.Program, pkg *ssa.Package) []string {
+	seen := map[string]bool{}
+	for _, mem := range pkg.Members {
+		if !ast.IsExported(mem.Name()) {
+			continue
+		}
+		switch mem := mem.(type) {
+		case *ssa.Function:
+			seen[funcName(mem)] = true
+		case *ssa.Type:
+			namedType, ok := mem.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			for i := 0; i < namedType.NumMethods(); i++ {
+				if m := namedType.Method(i); m.Exported() {
+					seen[funcName(prog.FuncValue(m))] = true
+				}
+			}
+		}
+	}
+	names := []string{}
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Print, for each imported non-standard package outside of the -usages
+// packages, the exported functions and methods which are actually used, how
+// often, and what fraction of the package's exported API that is. Packages
+// are grouped by repository root.
+func printDependencies(prog *ssa.Program, calls map[string]int) {
+	byRoot := map[string][]*ssa.Package{}
+	for _, pkg := range prog.AllPackages() {
+		path := pkg.Object.Path()
+		if _, ok := stdPkgs[path]; ok || usagesMatcher.MatchString(path) {
+			continue
+		}
+		root := repoRoot(path)
+		byRoot[root] = append(byRoot[root], pkg)
+	}
+	roots := []string{}
+	for root := range byRoot {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+
+	percent := func(used, total int) int {
+		if total == 0 {
+			return 0
+		}
+		return 100 * used / total
+	}
+
+	for _, root := range roots {
+		pkgs := byRoot[root]
+		sort.Sort(byPath(pkgs))
+
+		rootUsed, rootTotal := 0, 0
+		lines := []string{}
+		for _, pkg := range pkgs {
+			api := []string{}
+			for _, name := range exportedAPI(prog, pkg) {
+				if !dirs.ignored(name) {
+					api = append(api, name)
+				}
+			}
+			used := []string{}
+			for _, name := range api {
+				if n := calls[name]; n > 0 {
+					used = append(used, fmt.Sprintf("\t\t%03d %s", n, name))
+				}
+			}
+			rootUsed += len(used)
+			rootTotal += len(api)
+			lines = append(lines, fmt.Sprintf("\t%s %d/%d (%d%%)",
+				pkg.Object.Path(), len(used), len(api), percent(len(used), len(api))))
+			lines = append(lines, used...)
+		}
+		fmt.Printf("%s %d/%d (%d%%)\n", root, rootUsed, rootTotal, percent(rootUsed, rootTotal))
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+	}
+}
+
+type byPath []*ssa.Package
+
+func (a byPath) Len() int           { return len(a) }
+func (a byPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byPath) Less(i, j int) bool { return a[i].Object.Path() < a[j].Object.Path() }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Source comment directives, in the style of //go:generate.
+const (
+	// in a function's doc comment, treat the function as used
+	directiveKeep = "//giveupthefunc:keep"
+	// in a function's doc comment, hide the function from reports
+	directiveIgnore = "//giveupthefunc:ignore"
+	// anywhere in a file, hide every function in the file from reports
+	directiveIgnoreFile = "//giveupthefunc:ignore-file"
+)
+
+// A directive found in the source.
+type directive struct {
+	pos  token.Position
+	text string
+
+	// the function the directive applies to, empty for file directives
+	name string
+}
+
+// The directives of the loaded program. Every report goes through them to
+// decide which functions and sites are hidden.
+type directives struct {
+	all []directive
+
+	keep   map[string]bool
+	ignore map[string]bool
+	// ignored files by filename
+	ignoreFile map[string]bool
+
+	fset    *token.FileSet
+	fnNames map[string]*ssa.Function
+}
+
+func newDirectives(fset *token.FileSet, fnNames map[string]*ssa.Function) *directives {
+	return &directives{
+		keep:       map[string]bool{},
+		ignore:     map[string]bool{},
+		ignoreFile: map[string]bool{},
+		fset:       fset,
+		fnNames:    fnNames,
+	}
+}
+
+// The directives of the analysed program, read in main.
+var dirs = newDirectives(nil, nil)
+
+// Read the directives from every file of the loaded program.
+func readDirectives(program *loader.Program, fnNames map[string]*ssa.Function) *directives {
+	fset := program.Fset
+	d := newDirectives(fset, fnNames)
+
+	// function directives are found through the function's declaration
+	attached := map[*ast.Comment]bool{}
+	decls := funcDecls(program)
+	for name, fn := range fnNames {
+		fd, ok := funcDecl(decls, fn)
+		if !ok || fd.Doc == nil {
+			continue
+		}
+		for _, c := range fd.Doc.List {
+			switch c.Text {
+			case directiveKeep:
+				d.keep[name] = true
+			case directiveIgnore:
+				d.ignore[name] = true
+			default:
+				continue
+			}
+			if !attached[c] {
+				attached[c] = true
+				d.all = append(d.all, directive{fset.Position(c.Pos()), c.Text, name})
+			}
+		}
+	}
+
+	// file directives, and function directives which aren't attached to a
+	// function, are found by looking at every comment
+	for _, info := range program.AllPackages {
+		for _, file := range info.Files {
+			for _, group := range file.Comments {
+				for _, c := range group.List {
+					switch {
+					case c.Text == directiveIgnoreFile:
+						pos := fset.Position(c.Pos())
+						d.ignoreFile[pos.Filename] = true
+						d.all = append(d.all, directive{pos, c.Text, ""})
+					case strings.HasPrefix(c.Text, "//giveupthefunc:") && !attached[c]:
+						d.all = append(d.all, directive{fset.Position(c.Pos()), c.Text, ""})
+					}
+				}
+			}
+		}
+	}
+	return d
+}
+
+// Is a function hidden from reports by an ignore directive or by the ignore
+// patterns of the config file?
+func (d *directives) ignored(name string) bool {
+	if d.ignore[name] || matchesAny(ignoreMatchers, name) {
+		return true
+	}
+	fn, ok := d.fnNames[name]
+	return ok && fn.Pos().IsValid() && d.ignoreFile[d.fset.Position(fn.Pos()).Filename]
+}
+
+// Is an instruction hidden from reports, because the function containing it
+// is ignored or it is in an ignored file?
+func (d *directives) ignoredInstr(ins ssa.Instruction) bool {
+	if d.ignored(funcName(enclosing(ins.Parent()))) {
+		return true
+	}
+	return len(d.ignoreFile) > 0 && d.ignoreFile[instrPosition(d.fset, ins).Filename]
+}
+
+// Is a site hidden from reports?
+func (d *directives) ignoredSite(s site) bool {
+	return d.ignoredInstr(s.instr)
+}
+
+// Is a function marked as used by a keep directive or the roots of the
+// config file? Such functions may have callers which can't be seen.
+func (d *directives) kept(name string) bool {
+	return d.keep[name] || matchesAny(rootMatchers, name)
+}
+
+// The mark shown after a kept function's name in text reports.
+func keptMark(name string) string {
+	if dirs.kept(name) {
+		return " (kept)"
+	}
+	return ""
+}
+
+// Print every directive, whether it was applied and whether it is stale. A
+// keep or ignore directive is stale once its function is used, an ignore-file
+// directive once nothing in its file is unused, and any directive which
+// isn't attached to a function or file is unknown or misplaced.
+func printDirectives(fset *token.FileSet, d *directives, calls map[string]int, fnNames map[string]*ssa.Function) {
+	unusedFiles := map[string]bool{}
+	for name, n := range calls {
+		fn := fnNames[name]
+		if n == 0 && !strings.Contains(name, "$") && fn.Pos().IsValid() {
+			unusedFiles[fset.Position(fn.Pos()).Filename] = true
+		}
+	}
+
+	lines := []positioned{}
+	for _, dir := range d.all {
+		status := "applied"
+		switch {
+		case dir.text == directiveIgnoreFile:
+			if !unusedFiles[dir.pos.Filename] {
+				status = "stale"
+			}
+		case dir.name == "":
+			status = "unknown or misplaced"
+		case calls[dir.name] > 0:
+			status = "stale"
+		}
+		text := fmt.Sprintf("%s %s", dir.text, status)
+		if dir.name != "" {
+			text = fmt.Sprintf("%s %s %s", dir.text, dir.name, status)
+		}
+		lines = append(lines, positioned{dir.pos, text})
+	}
+	sort.Sort(byPosition(lines))
+	for _, line := range lines {
+		fmt.Printf("%s: %s\n", line.pos, line.text)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestDirectives(t *testing.T) {
+	program, a := loadFixture(t, "fixture/directives")
+	dirs = readDirectives(program, a.fnNames)
+	defer func() { dirs = newDirectives(nil, nil) }()
+
+	for name, want := range map[string]bool{
+		"fixture/directives.hidden":        true,
+		"fixture/directives.inIgnoredFile": true,
+		"fixture/directives.Shown":         false,
+	} {
+		if got := dirs.ignored(name); got != want {
+			t.Errorf("ignored(%s) = %v, want %v", name, got, want)
+		}
+	}
+	if !dirs.kept("fixture/directives.kept") {
+		t.Error("kept function isn't kept")
+	}
+
+	shown := []string{}
+	for _, s := range a.v.sites["fixture/directives.target"] {
+		if !dirs.ignoredSite(s) {
+			shown = append(shown, funcName(s.caller))
+		}
+	}
+	if len(shown) != 1 || shown[0] != "fixture/directives.Shown" {
+		t.Errorf("expected only the site in Shown, got %v", shown)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Index every function declaration in the loaded program by the position of
+// its name, which is also the position of the function's types.Object.
+func funcDecls(program *loader.Program) map[token.Pos]*ast.FuncDecl {
+	decls := map[token.Pos]*ast.FuncDecl{}
+	for _, info := range program.AllPackages {
+		for _, file := range info.Files {
+			for _, decl := range file.Decls {
+				if fd, ok := decl.(*ast.FuncDecl); ok {
+					decls[fd.Name.Pos()] = fd
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// Find the declaration of a function, if it was loaded from source.
+func funcDecl(decls map[token.Pos]*ast.FuncDecl, fn *ssa.Function) (*ast.FuncDecl, bool) {
+	obj := fn.Object()
+	if obj == nil {
+		return nil, false
+	}
+	fd, ok := decls[obj.Pos()]
+	return fd, ok
+}
+
+// If a doc comment has a paragraph beginning with "Deprecated:", return
+// that paragraph.
+func deprecation(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		para = strings.TrimSpace(para)
+		if strings.HasPrefix(para, "Deprecated:") {
+			return strings.Join(strings.Fields(para), " "), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Functions smaller than this many instructions are too trivial for their
+// duplicates to be interesting.
+const minDupInstrs = 5
+
+// Compute a structural fingerprint of a function's SSA. The fingerprint
+// covers the shape of the control flow graph and the kind of each
+// instruction, but not register names or positions. An exact fingerprint also
+// covers types, constants and callees, while a loose one leaves them out so
+// functions which differ only in those are grouped as near duplicates.
+func fingerprint(fn *ssa.Function, exact bool) string {
+	var buf bytes.Buffer
+
+	// number values in order of definition so register names are ignored
+	local := map[ssa.Value]int{}
+	for _, p := range fn.Params {
+		local[p] = len(local)
+	}
+	for _, fv := range fn.FreeVars {
+		local[fv] = len(local)
+	}
+	for _, b := range fn.Blocks {
+		for _, ins := range b.Instrs {
+			if val, ok := ins.(ssa.Value); ok {
+				local[val] = len(local)
+			}
+		}
+	}
+
+	operand := func(val ssa.Value) string {
+		if val == nil {
+			return "_"
+		}
+		if n, ok := local[val]; ok {
+			return fmt.Sprintf("v%d", n)
+		}
+		if !exact {
+			return "k"
+		}
+		switch val := val.(type) {
+		case *ssa.Function:
+			// anonymous functions are named after their position, their
+			// bodies are covered below
+			if parent := val.Parent(); parent != nil {
+				for i, anon := range parent.AnonFuncs {
+					if anon == val {
+						return fmt.Sprintf("anon%d", i)
+					}
+				}
+			}
+			return funcName(val)
+		case *ssa.Const:
+			// names of constants are abbreviated
+			if val.Value == nil {
+				return fmt.Sprintf("nil:%s", val.Type())
+			}
+			return fmt.Sprintf("%s:%s", val.Value, val.Type())
+		default:
+			return val.String()
+		}
+	}
+
+	for _, b := range fn.Blocks {
+		fmt.Fprintf(&buf, "b%d", b.Index)
+		for _, succ := range b.Succs {
+			fmt.Fprintf(&buf, " ->%d", succ.Index)
+		}
+		buf.WriteString("\n")
+		for _, ins := range b.Instrs {
+			buf.WriteString(reflect.TypeOf(ins).Elem().Name())
+			if val, ok := ins.(ssa.Value); ok && exact {
+				fmt.Fprintf(&buf, ":%s", val.Type())
+			}
+			for _, op := range ins.Operands(nil) {
+				buf.WriteString(" ")
+				buf.WriteString(operand(*op))
+			}
+			buf.WriteString("\n")
+		}
+	}
+	for _, anon := range fn.AnonFuncs {
+		buf.WriteString(fingerprint(anon, exact))
+	}
+	return fmt.Sprintf("%x", sha1.Sum(buf.Bytes()))
+}
+
+// Group in-scope functions by fingerprint, keeping only groups with more than
+// one member. Each group is sorted, and groups are ordered by their first
+// member.
+func groupByFingerprint(names []string, fnNames map[string]*ssa.Function, exact bool) [][]string {
+	byPrint := map[string][]string{}
+	for _, name := range names {
+		fp := fingerprint(fnNames[name], exact)
+		byPrint[fp] = append(byPrint[fp], name)
+	}
+	groups := [][]string{}
+	for _, group := range byPrint {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+	sort.Sort(byFirst(groups))
+	return groups
+}
+
+type byFirst [][]string
+
+func (a byFirst) Len() int           { return len(a) }
+func (a byFirst) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byFirst) Less(i, j int) bool { return a[i][0] < a[j][0] }
+
+// Print groups of functions with identical or near identical bodies along
+// with their usage counts.
+func printDuplicates(calls map[string]int, fnNames map[string]*ssa.Function, shouldPrint func(name string) bool) {
+	names := []string{}
+	for name, fn := range fnNames {
+		if strings.Contains(name, "$") || !shouldPrint(name) {
+			continue
+		}
+		if fn.Synthetic != "" || instrCount(fn) < minDupInstrs {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	// a group of near duplicates which are all identical is reported once
+	identical := groupByFingerprint(names, fnNames, true)
+	seen := map[string]bool{}
+	for _, group := range identical {
+		seen[strings.Join(group, " ")] = true
+	}
+	similar := [][]string{}
+	for _, group := range groupByFingerprint(names, fnNames, false) {
+		if !seen[strings.Join(group, " ")] {
+			similar = append(similar, group)
+		}
+	}
+
+	for _, section := range []struct {
+		title  string
+		groups [][]string
+	}{
+		{"IDENTICAL:", identical},
+		{"SIMILAR:", similar},
+	} {
+		fmt.Println(section.title)
+		for i, group := range section.groups {
+			if i > 0 {
+				fmt.Println()
+			}
+			for _, name := range group {
+				fmt.Printf("%d %s\n", calls[name], name)
+			}
+		}
+	}
+}
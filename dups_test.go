@@ -0,0 +1,17 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdenticalFingerprints(t *testing.T) {
+	_, a := loadFixture(t, "fixture/dups")
+	names := []string{"fixture/dups.A", "fixture/dups.B", "fixture/dups.Log", "fixture/dups.Log2"}
+
+	got := groupByFingerprint(names, a.fnNames, true)
+	want := [][]string{{"fixture/dups.A", "fixture/dups.B"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("identical groups: got %v, want %v", got, want)
+	}
+}
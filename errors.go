@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// If a call returns an error, report the index of that error result.
+func errorResult(common *ssa.CallCommon) (int, bool) {
+	if _, ok := common.Value.(*ssa.Builtin); ok {
+		return 0, false
+	}
+	results := common.Signature().Results()
+	for i := results.Len() - 1; i >= 0; i-- {
+		if types.Identical(results.At(i).Type(), errorType) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Is the error returned by a call ever looked at? Errors from 'go' and
+// 'defer' are always discarded.
+func errorChecked(call ssa.CallInstruction, index int) bool {
+	n := call.Common().Signature().Results().Len()
+	return usedResults(call, n)[index]
+}
+
+// Print, for every callee which returns an error, how many call sites ignore
+// and check that error, followed by the position of every ignoring site.
+// Ignored callees and calls from ignored code are hidden.
+func printIgnoredErrors(fset *token.FileSet, callInstrs []ssa.CallInstruction) {
+	ignored := map[string]int{}
+	checked := map[string]int{}
+	sites := []positioned{}
+
+	for _, call := range callInstrs {
+		index, ok := errorResult(call.Common())
+		if !ok || dirs.ignoredInstr(call) {
+			continue
+		}
+		name := calleeName(call.Common())
+		if dirs.ignored(name) {
+			continue
+		}
+		if errorChecked(call, index) {
+			checked[name]++
+			continue
+		}
+		ignored[name]++
+		sites = append(sites, positioned{instrPosition(fset, call), name})
+	}
+
+	width := 1
+	for _, counts := range []map[string]int{ignored, checked} {
+		for _, n := range counts {
+			if w := len(fmt.Sprint(n)); w > width {
+				width = w
+			}
+		}
+	}
+
+	s := []string{}
+	for name, n := range ignored {
+		s = append(s, fmt.Sprintf("%0*d %0*d %s", width, n, width, checked[name], name))
+	}
+	sort.Strings(s)
+	sort.Sort(byPosition(sites))
+
+	fmt.Println("IGNORED ERRORS (ignored, checked):")
+	for i := range s {
+		fmt.Println(s[i])
+	}
+	fmt.Println("SITES:")
+	for _, site := range sites {
+		fmt.Printf("%s: %s\n", site.pos, site.text)
+	}
+}
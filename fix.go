@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// Roots are functions which are used even though nothing in the program
+// references them.
+func isRoot(fn *ssa.Function) bool {
+	if fn.Name() == "main" || fn.Name() == "init" {
+		return true
+	}
+	return isKept(funcName(fn))
+}
+
+// Is a function marked as a root by a keep directive or the config file, or
+// reachable from the exported API of a library package?
+func isKept(name string) bool {
+	return dirs.kept(name) || libraryRoots[name]
+}
+
+// List the names of every method declared by an interface in the loaded
+// program. An unexported method with one of these names may be satisfying an
+// interface, which isn't counted as a usage.
+func interfaceMethods(program *loader.Program) map[string]bool {
+	names := map[string]bool{}
+	for _, info := range program.AllPackages {
+		for _, tv := range info.Types {
+			iface, ok := tv.Type.Underlying().(*types.Interface)
+			if !ok {
+				continue
+			}
+			for i := 0; i < iface.NumMethods(); i++ {
+				names[iface.Method(i).Name()] = true
+			}
+		}
+	}
+	return names
+}
+
+// Collect the identifiers used by the .go files in the directories of the
+// loaded packages which weren't loaded themselves, such as test files and
+// files excluded by build constraints, keyed by package path. A function
+// named in one of these files may be used by it.
+func unloadedIdents(program *loader.Program) map[string]map[string]bool {
+	idents := map[string]map[string]bool{}
+	for pkg, info := range program.AllPackages {
+		if len(info.Files) == 0 {
+			continue
+		}
+		loaded := map[string]bool{}
+		for _, file := range info.Files {
+			loaded[program.Fset.File(file.Pos()).Name()] = true
+		}
+		dir := filepath.Dir(program.Fset.File(info.Files[0].Pos()).Name())
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		names := map[string]bool{}
+		for _, entry := range entries {
+			filename := filepath.Join(dir, entry.Name())
+			if entry.IsDir() || !strings.HasSuffix(filename, ".go") || loaded[filename] {
+				continue
+			}
+			// a file which doesn't parse is of no use to the go tool either
+			file, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+			if err != nil {
+				continue
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					names[id.Name] = true
+				}
+				return true
+			})
+		}
+		idents[pkg.Path()] = names
+	}
+	return idents
+}
+
+// Find the functions which are dead once every other dead function has been
+// removed. Removing a function removes the usages it makes, so functions
+// only used by dead functions are dead too. Only candidates are considered.
+func deadFunctions(calls map[string]int, sites map[string][]site, candidate func(name string) bool) []string {
+	counts := map[string]int{}
+	for name, n := range calls {
+		counts[name] = n
+	}
+	callees := map[string][]string{}
+	for callee, s := range sites {
+		for _, site := range s {
+			caller := funcName(site.caller)
+			callees[caller] = append(callees[caller], callee)
+		}
+	}
+
+	dead := []string{}
+	isDead := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for name, n := range counts {
+			if n != 0 || isDead[name] || !candidate(name) {
+				continue
+			}
+			isDead[name] = true
+			dead = append(dead, name)
+			changed = true
+			for _, callee := range callees[name] {
+				if _, ok := counts[callee]; ok {
+					counts[callee]--
+				}
+			}
+		}
+	}
+	sort.Strings(dead)
+	return dead
+}
+
+// Remove function declarations from the files of the loaded program along
+// with any imports they leave unused, returning the modified files.
+func removeDecls(program *loader.Program, remove []*ast.FuncDecl) map[*ast.File]*loader.PackageInfo {
+	doomed := map[*ast.FuncDecl]bool{}
+	for _, fd := range remove {
+		doomed[fd] = true
+	}
+
+	modified := map[*ast.File]*loader.PackageInfo{}
+	for _, info := range program.AllPackages {
+		for _, file := range info.Files {
+			decls := []ast.Decl{}
+			for _, decl := range file.Decls {
+				if fd, ok := decl.(*ast.FuncDecl); ok && doomed[fd] {
+					removeComments(file, fd)
+					modified[file] = info
+					continue
+				}
+				decls = append(decls, decl)
+			}
+			file.Decls = decls
+		}
+	}
+	for file, info := range modified {
+		removeUnusedImports(program.Fset, file, info)
+	}
+	return modified
+}
+
+// Remove the comments attached to or inside of a declaration, which the
+// printer would otherwise leave stranded.
+func removeComments(file *ast.File, fd *ast.FuncDecl) {
+	start, end := fd.Pos(), fd.End()
+	if fd.Doc != nil {
+		start = fd.Doc.Pos()
+	}
+	comments := []*ast.CommentGroup{}
+	for _, c := range file.Comments {
+		if c.Pos() >= start && c.End() <= end {
+			continue
+		}
+		comments = append(comments, c)
+	}
+	file.Comments = comments
+}
+
+// Remove imports which are no longer referenced by the file.
+func removeUnusedImports(fset *token.FileSet, file *ast.File, info *loader.PackageInfo) {
+	used := map[*types.PkgName]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if pkgName, ok := info.Uses[id].(*types.PkgName); ok {
+				used[pkgName] = true
+			}
+		}
+		return true
+	})
+	imports := append([]*ast.ImportSpec{}, file.Imports...)
+	for _, spec := range imports {
+		var obj types.Object
+		if spec.Name != nil {
+			if spec.Name.Name == "_" || spec.Name.Name == "." {
+				continue
+			}
+			obj = info.Defs[spec.Name]
+		} else {
+			obj = info.Implicits[spec]
+		}
+		pkgName, ok := obj.(*types.PkgName)
+		if !ok || used[pkgName] {
+			continue
+		}
+		astutil.DeleteImport(fset, file, strings.Trim(spec.Path.Value, "\"`"))
+	}
+}
+
+// Print a unified diff between two versions of a file using the system's
+// diff tool, as gofmt -d does.
+func printDiff(filename string, before, after []byte) error {
+	f1, err := ioutil.TempFile("", "giveupthefunc")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f1.Name())
+	defer f1.Close()
+	f2, err := ioutil.TempFile("", "giveupthefunc")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f2.Name())
+	defer f2.Close()
+
+	f1.Write(before)
+	f2.Write(after)
+	out, err := exec.Command("diff", "-u", "--label", filename+".orig", "--label", filename, f1.Name(), f2.Name()).Output()
+	if len(out) > 0 {
+		// diff exits with a status of 1 when the files differ
+		err = nil
+	}
+	os.Stdout.Write(out)
+	return err
+}
+
+// Write out, or print a diff of, every modified file.
+func writeFiles(fset *token.FileSet, files map[*ast.File]*loader.PackageInfo, dryRun bool) {
+	names := []string{}
+	byName := map[string]*ast.File{}
+	for file := range files {
+		name := fset.File(file.Pos()).Name()
+		names = append(names, name)
+		byName[name] = file
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, fset, byName[name]); err != nil {
+			fatalf("error formatting %s: %v", name, err)
+		}
+		if dryRun {
+			before, err := ioutil.ReadFile(name)
+			if err != nil {
+				fatalf("error reading %s: %v", name, err)
+			}
+			if err := printDiff(name, before, buf.Bytes()); err != nil {
+				fatalf("error computing diff for %s: %v", name, err)
+			}
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			fatalf("error writing %s: %v", name, err)
+		}
+		if err := ioutil.WriteFile(name, buf.Bytes(), fi.Mode().Perm()); err != nil {
+			fatalf("error writing %s: %v", name, err)
+		}
+	}
+}
+
+// Find the unexported functions and methods which can be removed from the
+// source, repeating until no more dead code is exposed.
+func removableFunctions(program *loader.Program, calls map[string]int, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool) []string {
+	decls := funcDecls(program)
+	ifaceMethods := interfaceMethods(program)
+	unloaded := unloadedIdents(program)
+
+	candidate := func(name string) bool {
+		fn := fnNames[name]
+		if strings.Contains(name, "$") || !shouldPrint(name) || fn.Pkg == nil || fn.Synthetic != "" {
+			return false
+		}
+		// functions in packages which weren't walked have no usages counted
+		if !usagesMatcher.MatchString(fn.Pkg.Object.Path()) {
+			return false
+		}
+		obj := fn.Object()
+		if obj == nil || obj.Exported() || isRoot(fn) {
+			return false
+		}
+		if fn.Signature.Recv() != nil && ifaceMethods[fn.Name()] {
+			return false
+		}
+		// tests and files for other platforms weren't analysed
+		if unloaded[fn.Pkg.Object.Path()][fn.Name()] {
+			return false
+		}
+		_, ok := funcDecl(decls, fn)
+		return ok
+	}
+	return deadFunctions(calls, sites, candidate)
+}
+
+// Remove unexported functions and methods with no usages from the source,
+// repeating until no more dead code is exposed.
+func fixUnused(program *loader.Program, calls map[string]int, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool, dryRun bool) {
+	decls := funcDecls(program)
+	dead := removableFunctions(program, calls, fnNames, sites, shouldPrint)
+	remove := []*ast.FuncDecl{}
+	for _, name := range dead {
+		fd, _ := funcDecl(decls, fnNames[name])
+		remove = append(remove, fd)
+		if !dryRun {
+			fmt.Printf("removing %s\n", name)
+		}
+	}
+	writeFiles(program.Fset, removeDecls(program, remove), dryRun)
+}
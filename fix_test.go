@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFixUnused(t *testing.T) {
+	program, a := loadFixture(t, "fixture/fix")
+	usagesMatcher = fixtureMatcher("fixture/fix")
+	defer func() { usagesMatcher = nil }()
+	all := func(name string) bool { return true }
+
+	dead := removableFunctions(program, a.calls, a.fnNames, a.v.sites, all)
+	want := []string{"fixture/fix.deadA", "fixture/fix.deadB"}
+	if !reflect.DeepEqual(dead, want) {
+		t.Fatalf("removable functions: got %v, want %v", dead, want)
+	}
+
+	decls := funcDecls(program)
+	remove := []*ast.FuncDecl{}
+	for _, name := range dead {
+		fd, _ := funcDecl(decls, a.fnNames[name])
+		remove = append(remove, fd)
+	}
+	modified := removeDecls(program, remove)
+	if len(modified) != 1 {
+		t.Fatalf("expected 1 modified file, got %d", len(modified))
+	}
+	for file := range modified {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, program.Fset, file); err != nil {
+			t.Fatal(err)
+		}
+		src := buf.String()
+		for _, gone := range []string{"deadA", "deadB", "fixture/dep"} {
+			if strings.Contains(src, gone) {
+				t.Errorf("%s wasn't removed:\n%s", gone, src)
+			}
+		}
+		for _, kept := range []string{"func Live", "func used", "func onlyTests", "func onlyIgnored"} {
+			if !strings.Contains(src, kept) {
+				t.Errorf("%s was removed:\n%s", kept, src)
+			}
+		}
+	}
+}
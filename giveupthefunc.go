@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"math"
 	"os"
 	"reflect"
@@ -12,7 +14,6 @@ import (
 
 	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/go/ssa"
-	"golang.org/x/tools/go/ssa/ssautil"
 	"golang.org/x/tools/go/types"
 )
 
@@ -63,6 +64,16 @@ func funcPackages(fn *ssa.Function) []string {
 	return typePackages(recv.Type())
 }
 
+// Does a regexp match any of the packages of a function?
+func packageMatches(re *regexp.Regexp, fn *ssa.Function) bool {
+	for _, pkg := range funcPackages(fn) {
+		if re.MatchString(pkg) {
+			return true
+		}
+	}
+	return false
+}
+
 // Function names sometimes show up twice. For instance
 //    (*github.com/yhat/giveupthefunc/test.Foo).UsedInAnon
 //    (github.com/yhat/giveupthefunc/test.Foo).UsedInAnon
@@ -71,6 +82,53 @@ func funcName(fn *ssa.Function) string {
 	return strings.Replace(fn.RelString(nil), "*", "", 1)
 }
 
+// A human readable name for the target of a call. Static calls use the
+// function's name, interface method calls the interface and method, and
+// calls of function values their signature.
+func calleeName(common *ssa.CallCommon) string {
+	if fn := common.StaticCallee(); fn != nil {
+		return funcName(fn)
+	}
+	if common.IsInvoke() {
+		return fmt.Sprintf("(%s).%s", common.Value.Type(), common.Method.Name())
+	}
+	return common.Signature().String()
+}
+
+// The position of an instruction, falling back to its function's position
+// for instructions which have none of their own.
+func instrPosition(fset *token.FileSet, ins ssa.Instruction) token.Position {
+	pos := ins.Pos()
+	if pos == token.NoPos {
+		pos = ins.Parent().Pos()
+	}
+	return fset.Position(pos)
+}
+
+// Some text attached to a position in the source.
+type positioned struct {
+	pos  token.Position
+	text string
+}
+
+type byPosition []positioned
+
+func (a byPosition) Len() int      { return len(a) }
+func (a byPosition) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byPosition) Less(i, j int) bool {
+	p, q := a[i].pos, a[j].pos
+	if p.Filename != q.Filename {
+		return p.Filename < q.Filename
+	}
+	if p.Line != q.Line {
+		return p.Line < q.Line
+	}
+	if p.Column != q.Column {
+		return p.Column < q.Column
+	}
+	return a[i].text < a[j].text
+}
+
 // is the function in the standard library?
 func inStandardPackages(fn *ssa.Function) bool {
 	pkgs := funcPackages(fn)
@@ -85,6 +143,27 @@ func inStandardPackages(fn *ssa.Function) bool {
 
 var (
 	includeStdPkgs bool
+	showHotness    bool
+	showSignatures bool
+	showErrors     bool
+	showSingleUse  bool
+	showDups       bool
+	showCycles     bool
+	showCoupling   bool
+	showLaunches   bool
+	showPanics     bool
+	showDeps       bool
+	showDeprecated bool
+	showBlame      bool
+	showTreemap    bool
+	interactive    bool
+	outputFormat   string
+	policyFile     string
+	fix            bool
+	dryRun         bool
+	unexport       bool
+	showDirectives bool
+	checkPolicy    bool
 	usagesMatcher  *regexp.Regexp
 	scopeMatcher   *regexp.Regexp
 )
@@ -103,18 +182,111 @@ func fatalf(format string, a ...interface{}) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			runHistory(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		}
+	}
+
 	var analysisScope string
 	var usages string
+	var public string
+	var library string
+	var configFile string
+	var showConfig bool
+	var consumers stringList
 
 	flag.StringVar(&usages, "usages", "", "a regexp to match packages to count function usages in, usage defaults to matching all import arguments")
 	flag.StringVar(&analysisScope, "scope", "", "a regexp to match packages who's function counds should be displayed, scope defaults to matching all import arguments")
+	flag.StringVar(&public, "public", "", "a regexp to match packages whose exported API is public and must not be unexported")
+	flag.StringVar(&library, "library", "", "a regexp to match library packages whose exported API, and everything it uses, is treated as used")
 	flag.BoolVar(&includeStdPkgs, "std", false, "if functions from standard packages should be included in analysis")
+	flag.BoolVar(&showHotness, "hot", false, "weight usages by loop nesting depth and rank functions by the weighted score")
+	flag.BoolVar(&showSignatures, "signatures", false, "report unused parameters, constant arguments and unused results")
+	flag.BoolVar(&showErrors, "errors", false, "report calls whose error result is discarded")
+	flag.BoolVar(&showSingleUse, "single", false, "report functions used exactly once, smallest first")
+	flag.BoolVar(&showDups, "dups", false, "report groups of functions with identical or near identical bodies")
+	flag.BoolVar(&showCycles, "cycles", false, "report directly and mutually recursive groups of functions")
+	flag.BoolVar(&showCoupling, "coupling", false, "report fan-in, fan-out and instability of functions and packages")
+	flag.BoolVar(&showLaunches, "launches", false, "report functions launched as goroutines or deferred")
+	flag.BoolVar(&showPanics, "panics", false, "report functions which panic, recover or can transitively reach a panic")
+	flag.BoolVar(&showDeps, "deps", false, "report which exported functions of each third party dependency are used")
+	flag.BoolVar(&showDeprecated, "deprecated", false, "report uses of functions documented as deprecated")
+	flag.BoolVar(&showBlame, "blame", false, "report when, by which commit and by whom each unused function was last changed, using git blame")
+	flag.BoolVar(&showTreemap, "svg", false, "write an SVG treemap of functions sized by instruction count and coloured by usage count")
+	flag.BoolVar(&interactive, "i", false, "browse the results interactively, filtering and sorting without reloading the program")
+	flag.StringVar(&policyFile, "policy", "", "a JSON policy file, violations are reported and cause a non-zero exit")
+	flag.BoolVar(&checkPolicy, "check", false, "check the policy from -policy or the config file, implied by -policy")
+	flag.BoolVar(&fix, "fix", false, "remove unexported functions and methods with no usages from the source")
+	flag.BoolVar(&unexport, "unexport", false, "rename exported functions and methods only used within their own package to their unexported form")
+	flag.BoolVar(&dryRun, "dry-run", false, "with -fix or -unexport, print a unified diff instead of rewriting files")
+	flag.BoolVar(&showDirectives, "directives", false, "list the //giveupthefunc: directives in the source and whether they are stale")
+	flag.StringVar(&outputFormat, "format", "text", "output format, either text, json or html (json is only supported by -coupling, html by the usage report)")
+	flag.StringVar(&configFile, "config", "", "the config file, defaults to the first "+configFilename+" found from the working directory upward")
+	flag.BoolVar(&showConfig, "print-config", false, "print the effective configuration and exit")
+	flag.Var(&consumers, "consumers", "local source trees of consumers of the library import paths given as arguments, their usage of the libraries is reported")
 
 	flag.Parse()
 	args := flag.Args()
 
+	cfg := &projectConfig{}
+	if configFile == "" {
+		if wd, err := os.Getwd(); err == nil {
+			configFile, _ = findConfig(wd)
+		}
+	}
+	if configFile != "" {
+		var err error
+		if cfg, err = loadConfig(configFile); err != nil {
+			fatalf("error loading config: %v", err)
+		}
+	}
+
+	// values from the config file are used unless the flag was given
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
 	if len(args) == 0 {
-		fatalf("usage: giveupthefunc [flags] [list of import paths]")
+		args = cfg.Packages
+	}
+	if !set["usages"] {
+		usages = cfg.Usages
+	}
+	if !set["scope"] {
+		analysisScope = cfg.Scope
+	}
+	if !set["public"] {
+		public = cfg.Public
+	}
+	if !set["library"] {
+		library = cfg.Library
+	}
+	if !set["std"] {
+		includeStdPkgs = cfg.Std
+	}
+	if !set["format"] && cfg.Format != "" {
+		outputFormat = cfg.Format
+	}
+
+	if len(args) == 0 {
+		fatalf("usage: giveupthefunc [flags] [list of import paths]\n       giveupthefunc history [flags] [list of import paths]\n       giveupthefunc serve [flags] [list of import paths]")
+	}
+	// html is only written by the usage report
+	otherReport := showHotness || showSignatures || showErrors || showSingleUse ||
+		showDups || showCycles || showCoupling || showLaunches || showPanics ||
+		showDeps || showDeprecated || showBlame || showTreemap || interactive ||
+		checkPolicy || policyFile != "" || fix || unexport || showDirectives ||
+		len(consumers) > 0
+	switch {
+	case outputFormat == "text":
+	case outputFormat == "json" && showCoupling:
+	case outputFormat == "html" && !otherReport:
+	default:
+		fatalf("unsupported output format %q", outputFormat)
 	}
 
 	if usages == "" {
@@ -125,8 +297,51 @@ func main() {
 	}
 	usagesMatcher = regexp.MustCompile(usages)
 	scopeMatcher = regexp.MustCompile(analysisScope)
+	var publicMatcher *regexp.Regexp
+	if public != "" {
+		publicMatcher = regexp.MustCompile(public)
+	}
+	if library != "" {
+		libraryMatcher = regexp.MustCompile(library)
+	}
+
+	pol := cfg.Policy
+	if policyFile != "" {
+		var err error
+		if pol, err = loadPolicy(policyFile); err != nil {
+			fatalf("error loading policy: %v", err)
+		}
+		checkPolicy = true
+	}
+	if checkPolicy && pol == nil {
+		fatalf("-check requires a policy from -policy or the config file")
+	}
+
+	rootMatchers, ignoreMatchers = cfg.rootMatchers, cfg.ignoreMatchers
 
-	config := &loader.Config{}
+	if showConfig {
+		cfg.Packages = args
+		cfg.Usages = usages
+		cfg.Scope = analysisScope
+		cfg.Public = public
+		cfg.Library = library
+		cfg.Std = includeStdPkgs
+		cfg.Format = outputFormat
+		cfg.Policy = pol
+		if policyFile != "" {
+			cfg.PolicyFile = policyFile
+		}
+		printConfig(cfg)
+		return
+	}
+
+	if len(consumers) > 0 {
+		printConsumerUsage(args, consumers)
+		return
+	}
+
+	// comments are needed for doc comments and directives
+	config := &loader.Config{ParserMode: parser.ParseComments}
 
 	for _, importpath := range args {
 		config.Import(importpath)
@@ -137,91 +352,119 @@ func main() {
 		fatalf("error loading: %v", err)
 	}
 
-	prog := ssa.Create(program, 0)
-	prog.BuildAll()
-
-	calls := map[string]int{}
-	// create a map of names to function values to use later
-	fnNames := map[string]*ssa.Function{}
-
-	// AllFunctions list all functions reachable by this set of programs.
-	funcs := ssautil.AllFunctions(prog)
-	pkgs := prog.AllPackages()
-	if len(pkgs) == 0 {
-		fatalf("no packages specified")
+	a, err := analyze(program, usagesMatcher)
+	if err != nil {
+		fatalf("error analyzing: %v", err)
 	}
+	prog, calls, fnNames, v := a.prog, a.calls, a.fnNames, a.v
 
-	for fn := range funcs {
+	dirs = readDirectives(program, fnNames)
 
-		name := funcName(fn)
-		fnNames[name] = fn
-		if includeStdPkgs || !inStandardPackages(fn) {
-			calls[name] = 0
+	max := 0
+	for _, n := range calls {
+		if n > max {
+			max = n
 		}
 	}
 
-	// the visitor will track function usages as it walks the ssa tree
-	v := &visitor{calls, make(map[interface{}]bool)}
+	shouldPrint := func(fn *ssa.Function) bool {
+		return packageMatches(scopeMatcher, fn)
+	}
 
-	for _, pkg := range pkgs {
-		pkgPath := pkg.Object.Path()
-		if !usagesMatcher.MatchString(pkgPath) {
-			continue
-		}
+	libraryRoots = findLibraryRoots(fnNames, v.sites)
 
-		// given a top level function, walk it looking for function usages
-		walkFunc := func(fn *ssa.Function) {
-			if fn.Pkg.Object.Path() != pkgPath {
-				return
-			}
-			for _, block := range fn.Blocks {
-				for i := range block.Instrs {
-					v.walkInstr(block.Instrs[i])
-				}
-			}
-			if fn.Recover != nil {
-				for i := range fn.Recover.Instrs {
-					v.walkInstr(fn.Recover.Instrs[i])
-				}
-			}
-			for i := range fn.AnonFuncs {
-				v.walkValue(fn.AnonFuncs[i])
-			}
-			return
-		}
+	inScope := func(name string) bool {
+		fn, ok := fnNames[name]
+		return ok && shouldPrint(fn) && !dirs.ignored(name)
+	}
 
-		for _, mem := range pkg.Members {
-			switch mem := mem.(type) {
-			case *ssa.Function:
-				walkFunc(mem)
-			case *ssa.Type:
-				// if the member is a *ssa.Type walk all methods on that type
-				namedType, ok := mem.Type().(*types.Named)
-				if !ok {
-					panic("global type is not a named type!")
-				}
-				for i := 0; i < namedType.NumMethods(); i++ {
-					fn := prog.FuncValue(namedType.Method(i))
-					walkFunc(fn)
-				}
-			case *ssa.Global:
-			}
-		}
+	if interactive {
+		newBrowser(a, prog.Fset, scopeMatcher).run(os.Stdin, os.Stdout)
+		return
 	}
-	max := 0
-	for _, n := range calls {
-		if n > max {
-			max = n
-		}
+	if showDirectives {
+		printDirectives(prog.Fset, dirs, calls, fnNames)
+		return
 	}
 
-	shouldPrint := func(fn *ssa.Function) bool {
-		for _, pkg := range funcPackages(fn) {
-			if scopeMatcher.MatchString(pkg) {
-				return true
-			}
+	if checkPolicy {
+		printViolations(pol.violations(prog.Fset, v.sites, v.stdSites, v.globals))
+		return
+	}
+	if fix {
+		fixUnused(program, calls, fnNames, v.sites, inScope, dryRun)
+		return
+	}
+	if unexport {
+		unexportUnused(program, fnNames, v.sites, inScope, publicMatcher, dryRun)
+		return
+	}
+	if showHotness {
+		printHotness(calls, v.sites, inScope)
+		return
+	}
+	if showSignatures {
+		printSignatures(fnNames, v.sites, interfaceMethods(program), inScope)
+		return
+	}
+	if showErrors {
+		printIgnoredErrors(prog.Fset, v.callInstrs)
+		return
+	}
+	if showSingleUse {
+		printSingleUse(prog.Fset, fnNames, v.sites, inScope)
+		return
+	}
+	if showDups {
+		printDuplicates(calls, fnNames, inScope)
+		return
+	}
+	if showCycles {
+		printCycles(v.sites, inScope)
+		return
+	}
+	if showCoupling {
+		printCoupling(computeCoupling(fnNames, v.sites, inScope), outputFormat)
+		return
+	}
+	if showLaunches {
+		printLaunches(prog.Fset, v.sites, v.callInstrs, inScope)
+		return
+	}
+	if showPanics {
+		printPanics(calls, fnNames, v.sites, inScope)
+		return
+	}
+	if showDeps {
+		printDependencies(prog, calls)
+		return
+	}
+	if showDeprecated {
+		printDeprecated(prog.Fset, funcDecls(program), fnNames, v.sites, v.stdSites)
+		return
+	}
+
+	// in library mode only the exported API and dead code are shown
+	shouldShow := inScope
+	if libraryMatcher != nil {
+		reachable := reachableFromRoots(fnNames, v.sites)
+		shouldShow = func(name string) bool {
+			return inScope(name) && (isLibraryAPI(fnNames[name]) || !reachable[name])
 		}
-		return false
+	}
+
+	if showBlame {
+		printBlame(prog.Fset, funcDecls(program), calls, fnNames, shouldShow)
+		return
+	}
+
+	if showTreemap {
+		printTreemap(calls, fnNames, shouldShow)
+		return
+	}
+	if outputFormat == "html" {
+		printHTML(buildHTMLReport(prog.Fset, calls, fnNames, v.sites, shouldShow))
+		return
 	}
 
 	max = int(math.Floor(math.Log10(float64(max)))) + 1
@@ -231,9 +474,8 @@ func main() {
 		if strings.Contains(name, "$") {
 			continue
 		}
-		fn := fnNames[name]
-		if shouldPrint(fn) {
-			s = append(s, fmt.Sprintf(formatter, n, name))
+		if shouldShow(name) {
+			s = append(s, fmt.Sprintf(formatter, n, name)+keptMark(name))
 		}
 	}
 	sort.Strings(s)
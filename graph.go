@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A graph of function names, mapping each caller to the set of functions it
+// references.
+type graph map[string]map[string]bool
+
+// Build the caller to callee graph from the sites gathered by the visitor.
+func callGraph(sites map[string][]site) graph {
+	g := graph{}
+	for callee, s := range sites {
+		for _, site := range s {
+			g.addEdge(funcName(site.caller), callee)
+		}
+	}
+	return g
+}
+
+// Build the caller to callee graph from static calls only, leaving out other
+// references such as passing a function as a value. A call within an
+// anonymous function is an edge from the anonymous function, not from the
+// function declaring it.
+func staticCallGraph(sites map[string][]site) graph {
+	g := graph{}
+	for callee, s := range sites {
+		for _, site := range s {
+			if _, ok := staticCall(site, callee); ok {
+				g.addEdge(funcName(site.instr.Parent()), callee)
+			}
+		}
+	}
+	return g
+}
+
+func (g graph) addEdge(from, to string) {
+	if g[from] == nil {
+		g[from] = map[string]bool{}
+	}
+	g[from][to] = true
+}
+
+// List every node in the graph, sorted.
+func (g graph) nodes() []string {
+	seen := map[string]bool{}
+	for from, edges := range g {
+		seen[from] = true
+		for to := range edges {
+			seen[to] = true
+		}
+	}
+	nodes := []string{}
+	for n := range seen {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// List the successors of a node, sorted.
+func (g graph) succs(n string) []string {
+	succs := []string{}
+	for to := range g[n] {
+		succs = append(succs, to)
+	}
+	sort.Strings(succs)
+	return succs
+}
+
+// Compute the strongly connected components of the graph using Tarjan's
+// algorithm. Components are returned in reverse topological order.
+func (g graph) sccs() [][]string {
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	sccs := [][]string{}
+
+	var connect func(n string)
+	connect = func(n string) {
+		index[n] = len(index)
+		lowlink[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, m := range g.succs(n) {
+			if _, ok := index[m]; !ok {
+				connect(m)
+				if lowlink[m] < lowlink[n] {
+					lowlink[n] = lowlink[m]
+				}
+			} else if onStack[m] && index[m] < lowlink[n] {
+				lowlink[n] = index[m]
+			}
+		}
+
+		if lowlink[n] == index[n] {
+			scc := []string{}
+			for {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[m] = false
+				scc = append(scc, m)
+				if m == n {
+					break
+				}
+			}
+			sort.Strings(scc)
+			sccs = append(sccs, scc)
+		}
+	}
+
+	for _, n := range g.nodes() {
+		if _, ok := index[n]; !ok {
+			connect(n)
+		}
+	}
+	return sccs
+}
+
+// Print every directly or mutually recursive group of functions along with
+// its size and whether anything outside the group references it. Groups
+// which nothing else references are dead code, even though every member has
+// a non-zero usage count.
+func printCycles(sites map[string][]site, shouldPrint func(name string) bool) {
+	g := callGraph(sites)
+
+	cycles := [][]string{}
+	for _, scc := range g.sccs() {
+		if len(scc) == 1 && !g[scc[0]][scc[0]] {
+			continue
+		}
+		printable := false
+		for _, n := range scc {
+			printable = printable || shouldPrint(n)
+		}
+		if !printable {
+			continue
+		}
+		cycles = append(cycles, scc)
+	}
+	sort.Sort(bySizeThenFirst(cycles))
+
+	width := 1
+	if len(cycles) > 0 {
+		width = len(fmt.Sprint(len(cycles[len(cycles)-1])))
+	}
+	for _, scc := range cycles {
+		members := map[string]bool{}
+		for _, n := range scc {
+			members[n] = true
+		}
+		// roots are used from outside
+		reachable := false
+		for _, n := range scc {
+			reachable = reachable || isKept(n)
+		}
+		for from, edges := range g {
+			if members[from] {
+				continue
+			}
+			for to := range edges {
+				reachable = reachable || members[to]
+			}
+		}
+		status := "unreachable"
+		if reachable {
+			status = "reachable"
+		}
+		fmt.Printf("%0*d %s %s\n", width, len(scc), status, strings.Join(scc, " "))
+	}
+}
+
+type bySizeThenFirst [][]string
+
+func (a bySizeThenFirst) Len() int      { return len(a) }
+func (a bySizeThenFirst) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a bySizeThenFirst) Less(i, j int) bool {
+	if len(a[i]) != len(a[j]) {
+		return len(a[i]) < len(a[j])
+	}
+	return a[i][0] < a[j][0]
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"go/build"
+	"go/parser"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// Load and analyse a package from testdata, counting usages within it and
+// the packages below it.
+func loadFixture(t *testing.T, importpath string) (*loader.Program, *analysis) {
+	gopath, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctxt := build.Default
+	ctxt.GOPATH = gopath
+	config := &loader.Config{Build: &ctxt, ParserMode: parser.ParseComments}
+	config.Import(importpath)
+	program, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := analyze(program, fixtureMatcher(importpath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program, a
+}
+
+// A regexp matching the import path of a fixture and the packages below it.
+func fixtureMatcher(importpath string) *regexp.Regexp {
+	return regexp.MustCompile("^" + regexp.QuoteMeta(importpath) + "(/|$)")
+}
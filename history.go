@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// A commit of a git repository.
+type commit struct {
+	hash string
+	time time.Time
+}
+
+// The analysis of a single commit.
+type snapshot struct {
+	Commit    string         `json:"commit"`
+	Time      time.Time      `json:"time"`
+	Functions int            `json:"functions"`
+	Unused    int            `json:"unused"`
+	Counts    map[string]int `json:"counts"`
+}
+
+// Run git in a directory, returning its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return string(out), nil
+}
+
+// List the commits in a revision range, oldest first.
+func revList(repo, revRange string) ([]commit, error) {
+	out, err := git(repo, "log", "--reverse", "--format=%H %ct", revRange)
+	if err != nil {
+		return nil, err
+	}
+	commits := []commit{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		sec, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit{fields[0], time.Unix(sec, 0).UTC()})
+	}
+	return commits, nil
+}
+
+// Find the import path of a directory from its location within GOPATH.
+func importRoot(dir string) (string, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		src := filepath.Join(gopath, "src") + string(filepath.Separator)
+		if strings.HasPrefix(abs, src) {
+			return filepath.ToSlash(strings.TrimPrefix(abs, src)), true
+		}
+	}
+	return "", false
+}
+
+// Analyse a program checked out into a GOPATH which takes precedence over
+// the default one.
+func analyzeCheckout(gopath string, args []string, usages *regexp.Regexp) (*analysis, error) {
+	ctxt := build.Default
+	ctxt.GOPATH = gopath + string(filepath.ListSeparator) + ctxt.GOPATH
+	config := &loader.Config{Build: &ctxt, ParserMode: parser.ParseComments}
+	for _, importpath := range args {
+		config.Import(importpath)
+	}
+	program, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return analyze(program, usages)
+}
+
+// Summarise the in-scope functions of an analysis.
+func takeSnapshot(c commit, a *analysis, scope *regexp.Regexp) snapshot {
+	s := snapshot{Commit: c.hash, Time: c.time, Counts: map[string]int{}}
+	for name, n := range a.calls {
+		fn := a.fnNames[name]
+		if strings.Contains(name, "$") || !scope.MatchString(funcPackage(fn)) {
+			continue
+		}
+		s.Functions++
+		if n == 0 && !isRoot(fn) {
+			s.Unused++
+		}
+		s.Counts[name] = n
+	}
+	return s
+}
+
+func printHistoryCSV(snapshots []snapshot, perFunction bool) {
+	w := csv.NewWriter(os.Stdout)
+	if perFunction {
+		w.Write([]string{"commit", "time", "function", "count"})
+	} else {
+		w.Write([]string{"commit", "time", "functions", "unused"})
+	}
+	for _, s := range snapshots {
+		t := s.Time.Format(time.RFC3339)
+		if !perFunction {
+			w.Write([]string{s.Commit, t, strconv.Itoa(s.Functions), strconv.Itoa(s.Unused)})
+			continue
+		}
+		names := []string{}
+		for name := range s.Counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			w.Write([]string{s.Commit, t, name, strconv.Itoa(s.Counts[name])})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fatalf("error writing csv: %v", err)
+	}
+}
+
+// Analyse every commit in a revision range, checking each one out into a
+// temporary worktree within its own GOPATH.
+func history(repo, revRange, root string, args []string) ([]snapshot, error) {
+	commits, err := revList(repo, revRange)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits in %s", revRange)
+	}
+
+	gopath, err := ioutil.TempDir("", "giveupthefunc")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(gopath)
+	worktree := filepath.Join(gopath, "src", filepath.FromSlash(root))
+	if err := os.MkdirAll(filepath.Dir(worktree), 0755); err != nil {
+		return nil, err
+	}
+	if _, err := git(repo, "worktree", "add", "--detach", worktree, commits[0].hash); err != nil {
+		return nil, err
+	}
+	defer git(repo, "worktree", "remove", "--force", worktree)
+
+	snapshots := []snapshot{}
+	for _, c := range commits {
+		if _, err := git(worktree, "checkout", "--quiet", "--detach", c.hash); err != nil {
+			return nil, err
+		}
+		a, err := analyzeCheckout(gopath, args, usagesMatcher)
+		if err != nil {
+			// a commit which doesn't build is skipped rather than ending
+			// the whole series
+			fmt.Fprintf(os.Stderr, "skipping %s: %v\n", c.hash, err)
+			continue
+		}
+		snapshots = append(snapshots, takeSnapshot(c, a, scopeMatcher))
+	}
+	return snapshots, nil
+}
+
+// The history command analyses every commit in a revision range of a local
+// git repository, producing a time series of the number of functions, the
+// number of unused functions and the usage count of each function. The
+// repository itself is left untouched.
+func runHistory(argv []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	repo := fs.String("repo", ".", "the local git repository, which must be within GOPATH unless -import-root is given")
+	revRange := fs.String("range", "", "the revision range to analyse, as understood by git log, such as v1.0..HEAD")
+	root := fs.String("import-root", "", "the import path of the repository's root directory, defaults to its location within GOPATH")
+	usages := fs.String("usages", "", "a regexp to match packages to count function usages in, defaults to matching all import arguments")
+	scope := fs.String("scope", "", "a regexp to match packages whose functions are counted, defaults to matching all import arguments")
+	format := fs.String("format", "csv", "output format, either csv or json")
+	perFunction := fs.Bool("functions", false, "with -format=csv, output the usage count of every function rather than totals")
+	fs.BoolVar(&includeStdPkgs, "std", false, "if functions from standard packages should be included in analysis")
+	fs.Parse(argv)
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fatalf("usage: giveupthefunc history [flags] [list of import paths]")
+	}
+	if *revRange == "" {
+		fatalf("history requires a revision range, such as -range v1.0..HEAD")
+	}
+	if *format != "csv" && *format != "json" {
+		fatalf("unsupported output format %q", *format)
+	}
+	if *usages == "" {
+		*usages = regexpOr(args)
+	}
+	if *scope == "" {
+		*scope = regexpOr(args)
+	}
+	usagesMatcher = regexp.MustCompile(*usages)
+	scopeMatcher = regexp.MustCompile(*scope)
+
+	if *root == "" {
+		var ok bool
+		if *root, ok = importRoot(*repo); !ok {
+			fatalf("%s is not within GOPATH, use -import-root", *repo)
+		}
+	}
+
+	snapshots, err := history(*repo, *revRange, *root, args)
+	if err != nil {
+		fatalf("error: %v", err)
+	}
+
+	if *format == "json" {
+		enc, err := json.MarshalIndent(snapshots, "", "  ")
+		if err != nil {
+			fatalf("error encoding json: %v", err)
+		}
+		os.Stdout.Write(append(enc, '\n'))
+		return
+	}
+	printHistoryCSV(snapshots, *perFunction)
+}
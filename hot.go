@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Loops nested deeper than this are weighted as if they were this deep, which
+// keeps scores from overflowing on pathological code.
+const maxLoopDepth = 9
+
+// A static estimate of how hot a function is. Each site referencing the
+// function contributes 10^depth, where depth is the loop nesting depth of the
+// referencing instruction.
+type hotness struct {
+	name   string
+	count  int
+	score  int
+	inLoop bool
+}
+
+func newHotness(name string, count int, sites []site) hotness {
+	h := hotness{name: name, count: count}
+	for _, s := range sites {
+		depth := s.depth
+		if depth > maxLoopDepth {
+			depth = maxLoopDepth
+		}
+		weight := 1
+		for i := 0; i < depth; i++ {
+			weight *= 10
+		}
+		h.score += weight
+		if s.depth > 0 {
+			h.inLoop = true
+		}
+	}
+	return h
+}
+
+type byScore []hotness
+
+func (a byScore) Len() int      { return len(a) }
+func (a byScore) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byScore) Less(i, j int) bool {
+	if a[i].score != a[j].score {
+		return a[i].score < a[j].score
+	}
+	return a[i].name < a[j].name
+}
+
+// Print the weighted score, the raw count and whether the function is ever
+// referenced from within a loop, ordered by score. Kept functions are marked,
+// since they may have uses which can't be seen.
+func printHotness(calls map[string]int, sites map[string][]site, shouldPrint func(name string) bool) {
+	hot := []hotness{}
+	for name, n := range calls {
+		if strings.Contains(name, "$") || !shouldPrint(name) {
+			continue
+		}
+		hot = append(hot, newHotness(name, n, sites[name]))
+	}
+	sort.Sort(byScore(hot))
+
+	scoreWidth, countWidth := 1, 1
+	for _, h := range hot {
+		if w := len(fmt.Sprint(h.score)); w > scoreWidth {
+			scoreWidth = w
+		}
+		if w := len(fmt.Sprint(h.count)); w > countWidth {
+			countWidth = w
+		}
+	}
+	for _, h := range hot {
+		loop := "-"
+		if h.inLoop {
+			loop = "L"
+		}
+		fmt.Printf("%0*d %0*d %s %s%s\n", scoreWidth, h.score, countWidth, h.count, loop, h.name, keptMark(h.name))
+	}
+}
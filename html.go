@@ -0,0 +1,293 @@
+package main
+
+import (
+	"go/token"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// The data of an HTML report, which is embedded in the page as JSON.
+type htmlReport struct {
+	Functions []htmlFunc `json:"functions"`
+	Packages  []htmlPkg  `json:"packages"`
+	Files     []htmlFile `json:"files"`
+}
+
+type htmlFunc struct {
+	Name    string     `json:"name"`
+	Package string     `json:"package"`
+	Count   int        `json:"count"`
+	Unused  bool       `json:"unused"`
+	File    int        `json:"file"`
+	Line    int        `json:"line"`
+	Sites   []htmlSite `json:"sites"`
+}
+
+type htmlSite struct {
+	Caller string `json:"caller"`
+	File   int    `json:"file"`
+	Line   int    `json:"line"`
+}
+
+type htmlPkg struct {
+	Path      string `json:"path"`
+	Functions int    `json:"functions"`
+	Unused    int    `json:"unused"`
+}
+
+type htmlFile struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// Files of an HTML report by index, each is read once. Files which can't be
+// read have the index -1.
+type htmlFiles struct {
+	index map[string]int
+	files []htmlFile
+}
+
+func (f *htmlFiles) add(filename string) int {
+	if i, ok := f.index[filename]; ok {
+		return i
+	}
+	i := -1
+	if src, err := ioutil.ReadFile(filename); err == nil {
+		i = len(f.files)
+		f.files = append(f.files, htmlFile{filename, string(src)})
+	}
+	f.index[filename] = i
+	return i
+}
+
+type byHTMLName []htmlFunc
+
+func (a byHTMLName) Len() int           { return len(a) }
+func (a byHTMLName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byHTMLName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+type byHTMLPath []htmlPkg
+
+func (a byHTMLPath) Len() int           { return len(a) }
+func (a byHTMLPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byHTMLPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
+
+// Collect the data of an HTML report: every function to show with its
+// usage count and call sites, a summary of each package and the source of
+// every file declaring or calling one of the functions.
+func buildHTMLReport(fset *token.FileSet, calls map[string]int, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool) *htmlReport {
+	files := &htmlFiles{index: map[string]int{}}
+	pkgs := map[string]*htmlPkg{}
+	r := &htmlReport{Functions: []htmlFunc{}, Packages: []htmlPkg{}}
+	for name, n := range calls {
+		if strings.Contains(name, "$") || !shouldPrint(name) {
+			continue
+		}
+		fn := fnNames[name]
+		f := htmlFunc{Name: name, Package: funcPackage(fn), Count: n, File: -1}
+		f.Unused = n == 0 && !isRoot(fn)
+		if fn.Pos().IsValid() {
+			pos := fset.Position(fn.Pos())
+			f.File, f.Line = files.add(pos.Filename), pos.Line
+		}
+		f.Sites = []htmlSite{}
+		for _, s := range sites[name] {
+			pos := instrPosition(fset, s.instr)
+			f.Sites = append(f.Sites, htmlSite{funcName(s.caller), files.add(pos.Filename), pos.Line})
+		}
+		r.Functions = append(r.Functions, f)
+
+		pkg, ok := pkgs[f.Package]
+		if !ok {
+			pkg = &htmlPkg{Path: f.Package}
+			pkgs[f.Package] = pkg
+		}
+		pkg.Functions++
+		if f.Unused {
+			pkg.Unused++
+		}
+	}
+	sort.Sort(byHTMLName(r.Functions))
+	for _, pkg := range pkgs {
+		r.Packages = append(r.Packages, *pkg)
+	}
+	sort.Sort(byHTMLPath(r.Packages))
+	r.Files = append([]htmlFile{}, files.files...)
+	return r
+}
+
+// Write a self contained HTML report to stdout. Everything the page needs
+// is embedded so it can be viewed offline.
+func printHTML(r *htmlReport) {
+	if err := htmlTemplate.Execute(os.Stdout, r); err != nil {
+		fatalf("error writing html: %v", err)
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>giveupthefunc</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 0; display: flex; height: 100vh; }
+#list, #source { overflow: auto; padding: 8px; }
+#list { flex: 1; border-right: 1px solid #ccc; }
+#source { flex: 1; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 2px 6px; }
+th { cursor: pointer; background: #eee; position: sticky; top: 0; }
+tr.fn { cursor: pointer; }
+tr.fn:hover { background: #f4f4f4; }
+tr.selected { background: #dde8ff; }
+.unused { color: #c00; }
+.num { text-align: right; font-family: monospace; }
+pre { margin: 0; font-size: 12px; }
+pre span { display: block; }
+pre span:before { content: attr(data-line); display: inline-block; width: 4em; color: #999; }
+pre .decl { background: #eee; }
+pre .site { background: #ffeb99; }
+a { color: #03c; cursor: pointer; }
+</style>
+</head>
+<body>
+<div id="list">
+<h2>Packages</h2>
+<table id="packages">
+<tr><th>Package</th><th class="num">Functions</th><th class="num">Unused</th></tr>
+{{range .Packages}}<tr><td><a data-package="{{.Path}}">{{.Path}}</a></td><td class="num">{{.Functions}}</td><td class="num">{{.Unused}}</td></tr>
+{{end}}</table>
+<h2>Functions</h2>
+<p>
+<input id="filter" placeholder="filter by name">
+<select id="package"><option value="">all packages</option>{{range .Packages}}<option>{{.Path}}</option>{{end}}</select>
+<label><input id="unused" type="checkbox"> unused only</label>
+</p>
+<table>
+<thead><tr><th data-sort="count" class="num">Count</th><th data-sort="name">Function</th><th data-sort="package">Package</th></tr></thead>
+<tbody id="functions"></tbody>
+</table>
+</div>
+<div id="source"><p>Select a function to view its source.</p></div>
+<script>
+var report = {{.}};
+var byName = {};
+report.functions.forEach(function(f) { byName[f.name] = f; });
+var sortKey = "name", sortDesc = false, selected = null;
+
+function el(tag, text, cls) {
+	var e = document.createElement(tag);
+	if (text !== undefined) e.textContent = text;
+	if (cls) e.className = cls;
+	return e;
+}
+
+function renderFunctions() {
+	var filter = document.getElementById("filter").value.toLowerCase();
+	var pkg = document.getElementById("package").value;
+	var unused = document.getElementById("unused").checked;
+	var fns = report.functions.filter(function(f) {
+		return f.name.toLowerCase().indexOf(filter) >= 0 &&
+			(pkg === "" || f.package === pkg) && (!unused || f.unused);
+	});
+	fns.sort(function(a, b) {
+		var x = a[sortKey], y = b[sortKey];
+		var c = x < y ? -1 : x > y ? 1 : 0;
+		if (c === 0 && sortKey !== "name") c = a.name < b.name ? -1 : 1;
+		return sortDesc ? -c : c;
+	});
+	var body = document.getElementById("functions");
+	body.innerHTML = "";
+	fns.forEach(function(f) {
+		var tr = el("tr", undefined, "fn" + (f === selected ? " selected" : ""));
+		tr.appendChild(el("td", f.count, "num" + (f.unused ? " unused" : "")));
+		tr.appendChild(el("td", f.name, f.unused ? "unused" : ""));
+		tr.appendChild(el("td", f.package));
+		tr.onclick = function() { show(f); };
+		body.appendChild(tr);
+	});
+}
+
+function show(f) {
+	selected = f;
+	renderFunctions();
+	var src = document.getElementById("source");
+	src.innerHTML = "";
+	src.appendChild(el("h2", f.name));
+	src.appendChild(el("p", f.count + " usages" + (f.unused ? ", unused" : "")));
+	if (f.sites.length > 0) {
+		src.appendChild(el("h3", "Callers"));
+		var ul = el("ul");
+		f.sites.forEach(function(s) {
+			var li = el("li");
+			var caller = byName[s.caller];
+			var a = el(caller ? "a" : "span", s.caller);
+			if (caller) a.onclick = function() { show(caller); };
+			li.appendChild(a);
+			if (s.file >= 0) {
+				var loc = el("a", " " + report.files[s.file].name + ":" + s.line);
+				loc.onclick = function() { showSource(f, s.file, s.line); };
+				li.appendChild(loc);
+			}
+			ul.appendChild(li);
+		});
+		src.appendChild(ul);
+	}
+	if (f.file >= 0) {
+		showSource(f, f.file, f.line);
+	} else if (f.sites.length > 0 && f.sites[0].file >= 0) {
+		showSource(f, f.sites[0].file, f.sites[0].line);
+	}
+}
+
+function showSource(f, file, line) {
+	var src = document.getElementById("source");
+	var old = document.getElementById("code");
+	if (old) src.removeChild(old.parentNode);
+	var div = el("div");
+	div.appendChild(el("h3", report.files[file].name));
+	var pre = el("pre");
+	pre.id = "code";
+	var sites = {};
+	f.sites.forEach(function(s) { if (s.file === file) sites[s.line] = true; });
+	var target = null;
+	report.files[file].source.split("\n").forEach(function(text, i) {
+		var n = i + 1;
+		var cls = sites[n] ? "site" : (f.file === file && f.line === n ? "decl" : "");
+		var span = el("span", text, cls);
+		span.setAttribute("data-line", n);
+		if (n === line) target = span;
+		pre.appendChild(span);
+	});
+	div.appendChild(pre);
+	src.appendChild(div);
+	if (target) target.scrollIntoView();
+}
+
+document.getElementById("filter").oninput = renderFunctions;
+document.getElementById("package").onchange = renderFunctions;
+document.getElementById("unused").onchange = renderFunctions;
+Array.prototype.forEach.call(document.querySelectorAll("th[data-sort]"), function(th) {
+	th.onclick = function() {
+		var key = th.getAttribute("data-sort");
+		sortDesc = key === sortKey ? !sortDesc : key === "count";
+		sortKey = key;
+		renderFunctions();
+	};
+});
+Array.prototype.forEach.call(document.querySelectorAll("a[data-package]"), function(a) {
+	a.onclick = function() {
+		document.getElementById("package").value = a.getAttribute("data-package");
+		renderFunctions();
+	};
+});
+renderFunctions();
+</script>
+</body>
+</html>
+`))
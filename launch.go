@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// How a function was used at a site.
+const (
+	launchGo    = "go"
+	launchDefer = "defer"
+	launchCall  = "call"
+	launchOther = "other"
+)
+
+// Classify a site as launching the named function as a goroutine, deferring
+// it, calling it normally or some other reference such as passing it as a
+// value.
+func launchKind(s site, name string) string {
+	call, ok := staticCall(s, name)
+	if !ok {
+		return launchOther
+	}
+	switch call.(type) {
+	case *ssa.Go:
+		return launchGo
+	case *ssa.Defer:
+		return launchDefer
+	}
+	return launchCall
+}
+
+// Print, for every in-scope function, how many times it is launched as a
+// goroutine, deferred, called normally or otherwise referenced, with the
+// positions of its 'go' and 'defer' sites. This is followed by every
+// function in the program used as a goroutine entry point.
+func printLaunches(fset *token.FileSet, sites map[string][]site, callInstrs []ssa.CallInstruction, shouldPrint func(name string) bool) {
+	names := []string{}
+	for name := range sites {
+		if !strings.Contains(name, "$") && shouldPrint(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Println("LAUNCHES (go, defer, call, other):")
+	for _, name := range names {
+		counts := map[string]int{}
+		launches := []positioned{}
+		for _, s := range sites[name] {
+			if dirs.ignoredSite(s) {
+				continue
+			}
+			kind := launchKind(s, name)
+			counts[kind]++
+			if kind == launchGo || kind == launchDefer {
+				launches = append(launches, positioned{instrPosition(fset, s.instr), kind})
+			}
+		}
+		if counts[launchGo]+counts[launchDefer] == 0 {
+			continue
+		}
+		fmt.Printf("%03d %03d %03d %03d %s\n", counts[launchGo], counts[launchDefer], counts[launchCall], counts[launchOther], name)
+		sort.Sort(byPosition(launches))
+		for _, l := range launches {
+			fmt.Printf("\t%s %s\n", l.text, l.pos)
+		}
+	}
+
+	// goroutines are often launched from closures or from the standard
+	// library, so look at every 'go' instruction rather than at the sites
+	entries := map[string]int{}
+	for _, call := range callInstrs {
+		if _, ok := call.(*ssa.Go); !ok || dirs.ignoredInstr(call) {
+			continue
+		}
+		if name := calleeName(call.Common()); !dirs.ignored(name) {
+			entries[name]++
+		}
+	}
+	s := []string{}
+	for name, n := range entries {
+		s = append(s, fmt.Sprintf("%03d %s", n, name))
+	}
+	sort.Strings(s)
+	fmt.Println("GOROUTINE ENTRY FUNCTIONS:")
+	for i := range s {
+		fmt.Println(s[i])
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"regexp"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// Packages matching -library have their exported API treated as used.
+var libraryMatcher *regexp.Regexp
+
+// The functions reachable from the exported API of library packages, which
+// are roots. Set in main.
+var libraryRoots = map[string]bool{}
+
+// Is a function part of the exported API of a library package? That is an
+// exported function, or an exported method of an exported type.
+func isLibraryAPI(fn *ssa.Function) bool {
+	if libraryMatcher == nil || fn.Pkg == nil || fn.Synthetic != "" {
+		return false
+	}
+	if !libraryMatcher.MatchString(fn.Pkg.Object.Path()) {
+		return false
+	}
+	obj := fn.Object()
+	if obj == nil || !obj.Exported() {
+		return false
+	}
+	recv := fn.Signature.Recv()
+	if recv == nil {
+		return true
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Exported()
+}
+
+// List every function reachable from the given functions.
+func reachableFrom(g graph, from []string) map[string]bool {
+	reached := map[string]bool{}
+	stack := append([]string{}, from...)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reached[n] {
+			continue
+		}
+		reached[n] = true
+		for to := range g[n] {
+			stack = append(stack, to)
+		}
+	}
+	return reached
+}
+
+// Find the exported API of library packages and everything it transitively
+// references.
+func findLibraryRoots(fnNames map[string]*ssa.Function, sites map[string][]site) map[string]bool {
+	api := []string{}
+	for name, fn := range fnNames {
+		if isLibraryAPI(fn) {
+			api = append(api, name)
+		}
+	}
+	return reachableFrom(callGraph(sites), api)
+}
+
+// Find every function reachable from a root: main, init, kept functions and
+// the library roots.
+func reachableFromRoots(fnNames map[string]*ssa.Function, sites map[string][]site) map[string]bool {
+	roots := []string{}
+	for name, fn := range fnNames {
+		if isRoot(fn) {
+			roots = append(roots, name)
+		}
+	}
+	return reachableFrom(callGraph(sites), roots)
+}
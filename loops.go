@@ -0,0 +1,47 @@
+package main
+
+import (
+	"golang.org/x/tools/go/ssa"
+)
+
+// Compute the loop nesting depth of every block in a function. A loop is
+// found through a back edge, an edge from a block to one of its own
+// dominators, and its body is every block which can reach the back edge
+// without passing through the loop header. A block's depth is the number of
+// distinct loop headers whose body contains it.
+func loopDepths(fn *ssa.Function) map[*ssa.BasicBlock]int {
+	// loop bodies keyed by their header, so back edges which share a
+	// header (e.g. a 'continue') count as a single loop
+	bodies := map[*ssa.BasicBlock]map[*ssa.BasicBlock]bool{}
+
+	for _, b := range fn.Blocks {
+		for _, header := range b.Succs {
+			if !header.Dominates(b) {
+				continue
+			}
+			body, ok := bodies[header]
+			if !ok {
+				body = map[*ssa.BasicBlock]bool{header: true}
+				bodies[header] = body
+			}
+			stack := []*ssa.BasicBlock{b}
+			for len(stack) > 0 {
+				blk := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if body[blk] {
+					continue
+				}
+				body[blk] = true
+				stack = append(stack, blk.Preds...)
+			}
+		}
+	}
+
+	depths := map[*ssa.BasicBlock]int{}
+	for _, body := range bodies {
+		for blk := range body {
+			depths[blk]++
+		}
+	}
+	return depths
+}
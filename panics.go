@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Does a function contain an instruction matching the predicate? The
+// bodies of anonymous functions within it are not searched.
+func containsInstr(fn *ssa.Function, match func(ssa.Instruction) bool) bool {
+	for _, b := range fn.Blocks {
+		for _, ins := range b.Instrs {
+			if match(ins) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Add an edge from each function to the anonymous functions it calls or
+// defers. A closure which is only passed as a value isn't run by the
+// function declaring it.
+func addClosureCalls(g graph, fnNames map[string]*ssa.Function) {
+	for _, fn := range fnNames {
+		for _, b := range fn.Blocks {
+			for _, ins := range b.Instrs {
+				var common *ssa.CallCommon
+				switch x := ins.(type) {
+				case *ssa.Call:
+					common = &x.Call
+				case *ssa.Defer:
+					common = &x.Call
+				default:
+					continue
+				}
+				if callee := common.StaticCallee(); callee != nil && callee.Parent() != nil {
+					g.addEdge(funcName(fn), funcName(callee))
+				}
+			}
+		}
+	}
+}
+
+func isPanic(ins ssa.Instruction) bool {
+	_, ok := ins.(*ssa.Panic)
+	return ok
+}
+
+func isRecover(ins ssa.Instruction) bool {
+	call, ok := ins.(*ssa.Call)
+	if !ok {
+		return false
+	}
+	b, ok := call.Call.Value.(*ssa.Builtin)
+	return ok && b.Name() == "recover"
+}
+
+// Does a function defer a function which calls recover? Such a function
+// stops panics from propagating to its callers.
+func recovers(fn *ssa.Function) bool {
+	if fn.Recover == nil {
+		return false
+	}
+	return containsInstr(fn, func(ins ssa.Instruction) bool {
+		d, ok := ins.(*ssa.Defer)
+		if !ok {
+			return false
+		}
+		callee := d.Call.StaticCallee()
+		return callee != nil && containsInstr(callee, isRecover)
+	})
+}
+
+// Find the functions which explicitly panic, the functions which recover and
+// the callee through which each function can reach a panic without an
+// intervening recover, empty for functions which panic themselves.
+// Anonymous functions are analysed on their own but not returned, though
+// one may be the callee a panic is reached through.
+func reachPanics(fnNames map[string]*ssa.Function, sites map[string][]site) (panics, recovering map[string]bool, via map[string]string) {
+	panics = map[string]bool{}
+	recovering = map[string]bool{}
+	for name, fn := range fnNames {
+		if containsInstr(fn, isPanic) {
+			panics[name] = true
+		}
+		if recovers(fn) {
+			recovering[name] = true
+		}
+	}
+
+	// propagate panics from callees to callers until a fixpoint, recording
+	// the callee each panic was reached through. Only calls propagate a
+	// panic, not passing a function as a value.
+	g := staticCallGraph(sites)
+	addClosureCalls(g, fnNames)
+	via = map[string]string{}
+	for name := range panics {
+		if !recovering[name] {
+			via[name] = ""
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, caller := range g.nodes() {
+			if _, ok := via[caller]; ok || recovering[caller] {
+				continue
+			}
+			for _, callee := range g.succs(caller) {
+				if _, ok := via[callee]; ok {
+					via[caller] = callee
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for name := range fnNames {
+		if strings.Contains(name, "$") {
+			delete(panics, name)
+			delete(recovering, name)
+			delete(via, name)
+		}
+	}
+	return panics, recovering, via
+}
+
+// Print the functions which explicitly panic, the functions which recover
+// and the in-scope functions which can transitively reach a panic without
+// an intervening recover, each with its usage count. Only explicit calls to
+// panic are considered, not runtime errors such as nil dereferences.
+func printPanics(calls map[string]int, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool) {
+	panics, recovering, via := reachPanics(fnNames, sites)
+
+	section := func(title string, names []string, describe func(name string) string) {
+		s := []string{}
+		for _, name := range names {
+			if shouldPrint(name) {
+				s = append(s, fmt.Sprintf("%03d %s%s", calls[name], name, describe(name)))
+			}
+		}
+		sort.Strings(s)
+		fmt.Println(title)
+		for i := range s {
+			fmt.Println(s[i])
+		}
+	}
+	keys := func(m map[string]bool) []string {
+		names := []string{}
+		for name := range m {
+			names = append(names, name)
+		}
+		return names
+	}
+	none := func(string) string { return "" }
+
+	section("PANICS:", keys(panics), none)
+	section("RECOVERS:", keys(recovering), none)
+	reach := []string{}
+	for name := range via {
+		reach = append(reach, name)
+	}
+	section("MAY PANIC:", reach, func(name string) string {
+		if via[name] == "" {
+			return " (direct)"
+		}
+		return " via " + via[name]
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReachPanics(t *testing.T) {
+	_, a := loadFixture(t, "fixture/panics")
+	panics, _, via := reachPanics(a.fnNames, a.v.sites)
+
+	if callee, ok := via["fixture/panics.Calls"]; !ok || callee != "fixture/panics.boom" {
+		t.Errorf("Calls should panic through boom, got %q", callee)
+	}
+	for _, name := range []string{"Registers", "Setup", "Top", "Later"} {
+		if callee, ok := via["fixture/panics."+name]; ok {
+			t.Errorf("%s can't panic, but panics through %q", name, callee)
+		}
+	}
+	if panics["fixture/panics.Setup"] {
+		t.Error("Setup reported as panicking for its closure's panic")
+	}
+	for _, name := range []string{"Now", "Deferred"} {
+		if _, ok := via["fixture/panics."+name]; !ok {
+			t.Errorf("%s should panic through its closure", name)
+		}
+	}
+	for name := range via {
+		if strings.Contains(name, "$") {
+			t.Errorf("anonymous function %s reported", name)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"regexp"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// A policy is a set of rules about which functions may be used, and from
+// where, read from a JSON file such as
+//
+//	{
+//	    "banned": [
+//	        {"func": "^log\\.Fatal", "except": ["/cmd/"], "message": "return an error instead"},
+//	        {"func": "^net/http\\.DefaultClient$", "message": "use a client with a timeout"}
+//	    ],
+//	    "restricted": [
+//	        {"func": "^example.com/app/db\\.", "callers": ["/service$"]}
+//	    ]
+//	}
+type policy struct {
+	Banned     []*bannedRule     `json:"banned,omitempty"`
+	Restricted []*restrictedRule `json:"restricted,omitempty"`
+}
+
+// A function or package level variable which must not be used.
+type bannedRule struct {
+	// a regexp matched against the name of the function or variable
+	Func string `json:"func"`
+
+	// regexps matched against the import path of the using package, uses
+	// from a matching package are allowed
+	Except []string `json:"except,omitempty"`
+
+	// shown alongside each violation
+	Message string `json:"message,omitempty"`
+
+	funcMatcher    *regexp.Regexp
+	exceptMatchers []*regexp.Regexp
+}
+
+// A function or package level variable which may only be used from certain
+// packages. Uses from within the package declaring it are always allowed.
+type restrictedRule struct {
+	// a regexp matched against the name of the function or variable
+	Func string `json:"func"`
+
+	// regexps matched against the import path of the using package, uses
+	// from any other package are violations
+	Callers []string `json:"callers,omitempty"`
+
+	// shown alongside each violation
+	Message string `json:"message,omitempty"`
+
+	funcMatcher    *regexp.Regexp
+	callerMatchers []*regexp.Regexp
+}
+
+// Read a policy file and compile its patterns.
+func loadPolicy(filename string) (*policy, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p := &policy{}
+	if err := json.NewDecoder(f).Decode(p); err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", filename, err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return p, nil
+}
+
+// Compile patterns, reporting the first which fails along with its error.
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	matchers := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		m, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", pattern, err)
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+func (p *policy) compile() error {
+	var err error
+	for _, rule := range p.Banned {
+		if rule.funcMatcher, err = regexp.Compile(rule.Func); err != nil {
+			return err
+		}
+		if rule.exceptMatchers, err = compileAll(rule.Except); err != nil {
+			return err
+		}
+	}
+	for _, rule := range p.Restricted {
+		if rule.funcMatcher, err = regexp.Compile(rule.Func); err != nil {
+			return err
+		}
+		if rule.callerMatchers, err = compileAll(rule.Callers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The import path of the package a site is in.
+func sitePackage(s site) string {
+	if s.caller.Pkg == nil {
+		return ""
+	}
+	return s.caller.Pkg.Object.Path()
+}
+
+// The import path of the package declaring the function or variable a site
+// references.
+func declaringPackage(s site) string {
+	switch target := s.target.(type) {
+	case *ssa.Function:
+		return funcPackage(target)
+	case *ssa.Global:
+		return target.Pkg.Object.Path()
+	}
+	return ""
+}
+
+func isPackageInit(val ssa.Value) bool {
+	fn, ok := val.(*ssa.Function)
+	return ok && fn.Synthetic == "package initializer"
+}
+
+func matchesAny(matchers []*regexp.Regexp, s string) bool {
+	for _, m := range matchers {
+		if m.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check every reference against the policy's rules, returning a
+// description of each violation.
+func (p *policy) violations(fset *token.FileSet, refs ...map[string][]site) []positioned {
+	violations := []positioned{}
+	violate := func(s site, text, message string) {
+		// uses from ignored code aren't reported
+		if dirs.ignoredSite(s) {
+			return
+		}
+		if message != "" {
+			text += ": " + message
+		}
+		violations = append(violations, positioned{instrPosition(fset, s.instr), text})
+	}
+
+	for _, r := range refs {
+		for name, sites := range r {
+			// package initializers are called implicitly by imports
+			if len(sites) > 0 && isPackageInit(sites[0].target) {
+				continue
+			}
+			for _, rule := range p.Banned {
+				if !rule.funcMatcher.MatchString(name) {
+					continue
+				}
+				for _, s := range sites {
+					if !matchesAny(rule.exceptMatchers, sitePackage(s)) {
+						violate(s, fmt.Sprintf("%s uses banned %s", funcName(s.caller), name), rule.Message)
+					}
+				}
+			}
+			for _, rule := range p.Restricted {
+				if !rule.funcMatcher.MatchString(name) {
+					continue
+				}
+				for _, s := range sites {
+					pkg := sitePackage(s)
+					if pkg == declaringPackage(s) || matchesAny(rule.callerMatchers, pkg) {
+						continue
+					}
+					violate(s, fmt.Sprintf("%s uses restricted %s", funcName(s.caller), name), rule.Message)
+				}
+			}
+		}
+	}
+	sort.Sort(byPosition(violations))
+	return violations
+}
+
+// Print violations, exiting with a non-zero status if there were any.
+func printViolations(violations []positioned) {
+	for _, v := range violations {
+		fmt.Printf("%s: %s\n", v.pos, v.text)
+	}
+	if len(violations) > 0 {
+		os.Exit(1)
+	}
+}
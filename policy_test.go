@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolicyViolations(t *testing.T) {
+	program, a := loadFixture(t, "fixture/policy")
+	p := &policy{
+		Banned: []*bannedRule{
+			{Func: `^fixture/policy/lib\.Fatal$`, Except: []string{"/cmd$"}, Message: "return an error"},
+			// only ever called implicitly by importing packages
+			{Func: `^fixture/policy/lib\.init$`},
+		},
+		Restricted: []*restrictedRule{
+			{Func: `^fixture/policy/lib\.(Query|helper)$`, Callers: []string{"/service$"}},
+		},
+	}
+	if err := p.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, v := range p.violations(program.Fset, a.v.sites, a.v.stdSites, a.v.globals) {
+		got = append(got, v.text)
+	}
+	want := []string{
+		"fixture/policy/app.Run uses banned fixture/policy/lib.Fatal: return an error",
+		"fixture/policy/app.Run uses restricted fixture/policy/lib.Query",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected violations %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"go/parser"
+	"go/token"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// A function as returned by the server.
+type apiFunc struct {
+	Name     string `json:"name"`
+	Package  string `json:"package"`
+	Count    int    `json:"count"`
+	Unused   bool   `json:"unused"`
+	Position string `json:"position,omitempty"`
+}
+
+type apiSite struct {
+	Caller   string `json:"caller"`
+	Position string `json:"position"`
+}
+
+// A function with its call sites, callers and callees.
+type apiFuncDetail struct {
+	apiFunc
+	Sites   []apiSite `json:"sites"`
+	Callers []string  `json:"callers"`
+	Callees []string  `json:"callees"`
+}
+
+// The server loads and analyses the program once, and again on reload.
+type server struct {
+	args []string
+
+	mu   sync.RWMutex
+	fset *token.FileSet
+	a    *analysis
+	// the call graph including standard functions
+	graph graph
+}
+
+// Load and analyse the program, replacing the current analysis once done.
+func (s *server) load() error {
+	config := &loader.Config{ParserMode: parser.ParseComments}
+	for _, importpath := range s.args {
+		config.Import(importpath)
+	}
+	program, err := config.Load()
+	if err != nil {
+		return err
+	}
+	a, err := analyze(program, usagesMatcher)
+	if err != nil {
+		return err
+	}
+	g := callGraph(a.v.sites)
+	for callee, sites := range a.v.stdSites {
+		for _, site := range sites {
+			g.addEdge(funcName(site.caller), callee)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fset, s.a, s.graph = program.Fset, a, g
+	dirs = readDirectives(program, a.fnNames)
+	libraryRoots = findLibraryRoots(a.fnNames, a.v.sites)
+	return nil
+}
+
+func (s *server) count(name string) int {
+	if n, ok := s.a.calls[name]; ok {
+		return n
+	}
+	return len(s.a.v.stdSites[name])
+}
+
+func (s *server) sites(name string) []site {
+	if sites, ok := s.a.v.sites[name]; ok {
+		return sites
+	}
+	return s.a.v.stdSites[name]
+}
+
+func (s *server) apiFunc(name string) apiFunc {
+	fn := s.a.fnNames[name]
+	f := apiFunc{Name: name, Package: funcPackage(fn), Count: s.count(name)}
+	f.Unused = f.Count == 0 && !isRoot(fn)
+	if fn.Pos().IsValid() {
+		f.Position = s.fset.Position(fn.Pos()).String()
+	}
+	return f
+}
+
+// List the functions matching the query's filters: pkg and name regexps,
+// unused=true for only unused functions and std=true to include standard
+// functions. Functions outside of scope are never listed.
+func (s *server) functions(q map[string][]string) ([]apiFunc, error) {
+	get := func(key string) string {
+		if v := q[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	var pkgMatcher, nameMatcher *regexp.Regexp
+	var err error
+	if p := get("pkg"); p != "" {
+		if pkgMatcher, err = regexp.Compile(p); err != nil {
+			return nil, err
+		}
+	}
+	if n := get("name"); n != "" {
+		if nameMatcher, err = regexp.Compile(n); err != nil {
+			return nil, err
+		}
+	}
+	unused, std := get("unused") == "true", get("std") == "true"
+
+	names := []string{}
+	for name, fn := range s.a.fnNames {
+		if strings.Contains(name, "$") || dirs.ignored(name) {
+			continue
+		}
+		if inStandardPackages(fn) {
+			if !std {
+				continue
+			}
+		} else if !packageMatches(scopeMatcher, fn) {
+			continue
+		}
+		if pkgMatcher != nil && !pkgMatcher.MatchString(funcPackage(fn)) {
+			continue
+		}
+		if nameMatcher != nil && !nameMatcher.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	funcs := []apiFunc{}
+	for _, name := range names {
+		if f := s.apiFunc(name); !unused || f.Unused {
+			funcs = append(funcs, f)
+		}
+	}
+	return funcs, nil
+}
+
+// Describe a single function.
+func (s *server) function(name string) (apiFuncDetail, bool) {
+	if _, ok := s.a.fnNames[name]; !ok {
+		return apiFuncDetail{}, false
+	}
+	d := apiFuncDetail{apiFunc: s.apiFunc(name), Sites: []apiSite{}, Callers: []string{}}
+	callers := map[string]bool{}
+	for _, site := range s.sites(name) {
+		caller := funcName(site.caller)
+		d.Sites = append(d.Sites, apiSite{caller, instrPosition(s.fset, site.instr).String()})
+		if !callers[caller] {
+			callers[caller] = true
+			d.Callers = append(d.Callers, caller)
+		}
+	}
+	sort.Strings(d.Callers)
+	d.Callees = s.graph.succs(name)
+	return d, true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(append(enc, '\n'))
+}
+
+func (s *server) handleFunctions(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	funcs, err := s.functions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, funcs)
+}
+
+func (s *server) handleFunction(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.function(r.URL.Query().Get("name"))
+	if !ok {
+		http.Error(w, "no such function", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, d)
+}
+
+// The unused functions in scope, by package.
+func (s *server) handleUnused(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	funcs, err := s.functions(map[string][]string{"unused": {"true"}})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	byPkg := map[string][]string{}
+	for _, f := range funcs {
+		byPkg[f.Package] = append(byPkg[f.Package], f.Name)
+	}
+	writeJSON(w, byPkg)
+}
+
+// Reload and analyse the program. Queries are answered from the previous
+// analysis until the reload is done.
+func (s *server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "reload requires POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.load(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeJSON(w, map[string]int{"functions": len(s.a.fnNames)})
+}
+
+// The serve command loads the program once and answers queries about it
+// over a local HTTP/JSON API:
+//
+//	GET  /functions?pkg=&name=&unused=true&std=true
+//	GET  /function?name=
+//	GET  /unused
+//	POST /reload
+func runServe(argv []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:7070", "the address to listen on")
+	usages := fs.String("usages", "", "a regexp to match packages to count function usages in, defaults to matching all import arguments")
+	scope := fs.String("scope", "", "a regexp to match packages whose functions are listed, defaults to matching all import arguments")
+	fs.BoolVar(&includeStdPkgs, "std", false, "if functions from standard packages should be included in analysis")
+	fs.Parse(argv)
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fatalf("usage: giveupthefunc serve [flags] [list of import paths]")
+	}
+	if *usages == "" {
+		*usages = regexpOr(args)
+	}
+	if *scope == "" {
+		*scope = regexpOr(args)
+	}
+	usagesMatcher = regexp.MustCompile(*usages)
+	scopeMatcher = regexp.MustCompile(*scope)
+
+	s := &server{args: args}
+	if err := s.load(); err != nil {
+		fatalf("error loading: %v", err)
+	}
+	http.HandleFunc("/functions", s.handleFunctions)
+	http.HandleFunc("/function", s.handleFunction)
+	http.HandleFunc("/unused", s.handleUnused)
+	http.HandleFunc("/reload", s.handleReload)
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/exact"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// If a site is a static call to the named function, return the call. Calls
+// from synthetic wrappers, such as the $bound wrapper of a method value,
+// don't count since the wrapper itself is used as a value.
+func staticCall(s site, name string) (ssa.CallInstruction, bool) {
+	call, ok := s.instr.(ssa.CallInstruction)
+	if !ok || s.caller.Synthetic != "" {
+		return nil, false
+	}
+	callee := call.Common().StaticCallee()
+	if callee == nil || funcName(callee) != name {
+		return nil, false
+	}
+	return call, true
+}
+
+// List the static calls to a function. If the function is ever referenced
+// other than by calling it, for instance by being passed as a value, not all
+// of its callers can be known and ok is false.
+func allCalls(name string, sites []site) (calls []ssa.CallInstruction, ok bool) {
+	for _, s := range sites {
+		call, ok := staticCall(s, name)
+		if !ok {
+			return nil, false
+		}
+		calls = append(calls, call)
+	}
+	return calls, true
+}
+
+// Are two constants the same value of the same type? Constants are compared
+// by value since their names are abbreviated.
+func sameConst(a, b *ssa.Const) bool {
+	if !types.Identical(a.Type(), b.Type()) {
+		return false
+	}
+	if a.Value == nil || b.Value == nil {
+		return a.Value == nil && b.Value == nil
+	}
+	return exact.Compare(a.Value, token.EQL, b.Value)
+}
+
+// Does a value have any referrers?
+func isUsed(val ssa.Value) bool {
+	refs := val.Referrers()
+	return refs != nil && len(*refs) > 0
+}
+
+// Report which results of a call are never used. Results of 'go' and 'defer'
+// are always discarded.
+func usedResults(call ssa.CallInstruction, n int) []bool {
+	used := make([]bool, n)
+	val := call.Value()
+	if val == nil || !isUsed(val) {
+		return used
+	}
+	if n == 1 {
+		used[0] = true
+		return used
+	}
+	for _, ref := range *val.Referrers() {
+		if ext, ok := ref.(*ssa.Extract); ok && isUsed(ext) {
+			used[ext.Index] = true
+		}
+	}
+	return used
+}
+
+// Find signature simplifications: parameters the function body never reads,
+// parameters every caller passes the same constant and results no caller
+// uses. The signature of a method which may satisfy an interface, or of a
+// function used as a value, is fixed by that interface or func type, so
+// such functions are skipped.
+func findSignatures(fnNames map[string]*ssa.Function, sites map[string][]site, ifaceMethods map[string]bool, shouldPrint func(name string) bool) (unusedParams, constParams, unusedResults []string) {
+	unusedParams = []string{}
+	constParams = []string{}
+	unusedResults = []string{}
+
+	for name, fn := range fnNames {
+		// kept functions may have callers which can't be seen, such as
+		// callers through reflection, which rely on their signature
+		if strings.Contains(name, "$") || !shouldPrint(name) || dirs.kept(name) {
+			continue
+		}
+
+		// the receiver can't be removed from a method's signature
+		params := fn.Params
+		if fn.Signature.Recv() != nil && len(params) > 0 {
+			params = params[1:]
+		}
+		offset := len(fn.Params) - len(params)
+
+		if fn.Signature.Recv() != nil && ifaceMethods[fn.Name()] {
+			continue
+		}
+		calls, ok := allCalls(name, sites[name])
+		if !ok {
+			continue
+		}
+
+		if len(fn.Blocks) > 0 {
+			for _, p := range params {
+				if p.Name() != "_" && !isUsed(p) {
+					unusedParams = append(unusedParams, fmt.Sprintf("%s %s", name, p.Name()))
+				}
+			}
+		}
+
+		if len(calls) == 0 {
+			continue
+		}
+
+		for i, p := range params {
+			var constant *ssa.Const
+			for _, call := range calls {
+				args := call.Common().Args
+				c, ok := args[offset+i].(*ssa.Const)
+				if !ok || (constant != nil && !sameConst(c, constant)) {
+					constant = nil
+					break
+				}
+				constant = c
+			}
+			if constant != nil {
+				constParams = append(constParams, fmt.Sprintf("%s %s always %s", name, p.Name(), constant.Name()))
+			}
+		}
+
+		results := fn.Signature.Results()
+		used := make([]bool, results.Len())
+		for _, call := range calls {
+			for i, u := range usedResults(call, results.Len()) {
+				used[i] = used[i] || u
+			}
+		}
+		for i, u := range used {
+			if !u {
+				res := results.At(i)
+				unusedResults = append(unusedResults, fmt.Sprintf("%s result %d (%s)", name, i, res.Type()))
+			}
+		}
+	}
+	return unusedParams, constParams, unusedResults
+}
+
+// Print the signature simplifications found by findSignatures.
+func printSignatures(fnNames map[string]*ssa.Function, sites map[string][]site, ifaceMethods map[string]bool, shouldPrint func(name string) bool) {
+	unusedParams, constParams, unusedResults := findSignatures(fnNames, sites, ifaceMethods, shouldPrint)
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"PARAMETERS NEVER READ:", unusedParams},
+		{"PARAMETERS ALWAYS PASSED THE SAME CONSTANT:", constParams},
+		{"RESULTS NEVER USED:", unusedResults},
+	} {
+		fmt.Println(section.title)
+		sort.Strings(section.lines)
+		for _, line := range section.lines {
+			fmt.Println(line)
+		}
+	}
+}
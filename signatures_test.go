@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// The constants passed to the only parameter of a function at each call.
+func constArgs(t *testing.T, a *analysis, name string) []*ssa.Const {
+	calls, ok := allCalls(name, a.v.sites[name])
+	if !ok {
+		t.Fatalf("%s isn't only called", name)
+	}
+	consts := []*ssa.Const{}
+	for _, call := range calls {
+		consts = append(consts, call.Common().Args[0].(*ssa.Const))
+	}
+	return consts
+}
+
+func TestSameConst(t *testing.T) {
+	_, a := loadFixture(t, "fixture/signatures")
+
+	greet := constArgs(t, a, "fixture/signatures.greet")
+	if len(greet) != 2 || sameConst(greet[0], greet[1]) {
+		t.Errorf("long string constants with a common prefix compared equal")
+	}
+	same := constArgs(t, a, "fixture/signatures.same")
+	if len(same) != 2 || !sameConst(same[0], same[1]) {
+		t.Errorf("equal constants compared unequal")
+	}
+}
+
+func TestUnusedParams(t *testing.T) {
+	_, a := loadFixture(t, "fixture/signatures")
+	unusedParams, _, _ := findSignatures(a.fnNames, a.v.sites, map[string]bool{"Name": true}, func(string) bool { return true })
+	found := map[string]bool{}
+	for _, p := range unusedParams {
+		found[p] = true
+	}
+	if !found["fixture/signatures.unread n"] {
+		t.Errorf("unread's parameter not reported, got %v", unusedParams)
+	}
+	for _, p := range []string{"(fixture/signatures.T).Name prefix", "fixture/signatures.handler n"} {
+		if found[p] {
+			t.Errorf("%s reported though its signature is fixed", p)
+		}
+	}
+}
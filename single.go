@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// The number of SSA instructions in a function, including those of any
+// anonymous functions it declares.
+func instrCount(fn *ssa.Function) int {
+	n := 0
+	for _, b := range fn.Blocks {
+		n += len(b.Instrs)
+	}
+	for _, anon := range fn.AnonFuncs {
+		n += instrCount(anon)
+	}
+	return n
+}
+
+// A function referenced from exactly one site.
+type singleUse struct {
+	name   string
+	size   int
+	caller string
+
+	// where the caller lives relative to the function
+	locality string
+}
+
+type bySize []singleUse
+
+func (a bySize) Len() int      { return len(a) }
+func (a bySize) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a bySize) Less(i, j int) bool {
+	if a[i].size != a[j].size {
+		return a[i].size < a[j].size
+	}
+	return a[i].name < a[j].name
+}
+
+// Describe where a caller lives relative to the function it calls.
+func locality(fset *token.FileSet, fn, caller *ssa.Function) string {
+	if fn.Pkg == nil || caller.Pkg == nil || fn.Pkg != caller.Pkg {
+		return "other-package"
+	}
+	if fn.Pos().IsValid() && caller.Pos().IsValid() &&
+		fset.Position(fn.Pos()).Filename == fset.Position(caller.Pos()).Filename {
+		return "same-file"
+	}
+	return "same-package"
+}
+
+// Find the functions with exactly one reference which is a static call,
+// smallest first.
+func singleUses(fset *token.FileSet, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool) []singleUse {
+	single := []singleUse{}
+	for name, s := range sites {
+		// kept functions may have callers which can't be seen
+		if len(s) != 1 || strings.Contains(name, "$") || !shouldPrint(name) || dirs.kept(name) {
+			continue
+		}
+		// a function passed as a value may be called any number of times
+		if _, ok := staticCall(s[0], name); !ok {
+			continue
+		}
+		fn := fnNames[name]
+		caller := s[0].caller
+		single = append(single, singleUse{
+			name:     name,
+			size:     instrCount(fn),
+			caller:   funcName(caller),
+			locality: locality(fset, fn, caller),
+		})
+	}
+	sort.Sort(bySize(single))
+	return single
+}
+
+// Print the functions with exactly one reference which is a static call,
+// smallest first, since small single use helpers are the likeliest
+// candidates for inlining into or merging with their caller.
+func printSingleUse(fset *token.FileSet, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool) {
+	single := singleUses(fset, fnNames, sites, shouldPrint)
+	width := 1
+	for _, s := range single {
+		if w := len(fmt.Sprint(s.size)); w > width {
+			width = w
+		}
+	}
+	for _, s := range single {
+		fmt.Printf("%0*d %s <- %s (%s)\n", width, s.size, s.name, s.caller, s.locality)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestSingleUses(t *testing.T) {
+	program, a := loadFixture(t, "fixture/single")
+	single := map[string]bool{}
+	for _, s := range singleUses(program.Fset, a.fnNames, a.v.sites, func(string) bool { return true }) {
+		single[s.name] = true
+	}
+	if !single["fixture/single.helper"] {
+		t.Error("helper is called exactly once but not listed")
+	}
+	for _, name := range []string{"fixture/single.callback", "(fixture/single.T).m"} {
+		if single[name] {
+			t.Errorf("%s is only used as a value but listed", name)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package directives
+
+func target() {}
+
+//giveupthefunc:ignore
+func hidden() { target() }
+
+//giveupthefunc:keep
+func kept() {}
+
+func Shown() { target() }
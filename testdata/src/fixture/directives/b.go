@@ -0,0 +1,5 @@
+//giveupthefunc:ignore-file
+
+package directives
+
+func inIgnoredFile() { target() }
@@ -0,0 +1,31 @@
+package dups
+
+func apply(f func(int) int, n int) int { return f(n) }
+
+func A(n int) int {
+	if n > 0 {
+		return apply(func(x int) int { return x * 2 }, n)
+	}
+	return apply(func(x int) int { return x + 1 }, n)
+}
+
+func B(n int) int {
+	if n > 0 {
+		return apply(func(x int) int { return x * 2 }, n)
+	}
+	return apply(func(x int) int { return x + 1 }, n)
+}
+
+func Log(n int) string {
+	if n > 0 {
+		return "a rather long string constant, the first"
+	}
+	return "nothing to see"
+}
+
+func Log2(n int) string {
+	if n > 0 {
+		return "a rather long string constant, the second"
+	}
+	return "nothing to see"
+}
@@ -0,0 +1,20 @@
+package fix
+
+import "fixture/dep"
+
+// Live is exported, so it's never removed.
+func Live() { used() }
+
+func used() {}
+
+// deadA is unused, and deadB is only used by deadA, so both are removed
+// along with the import of dep.
+func deadA() { deadB() }
+
+func deadB() { dep.F() }
+
+// onlyTests is used by a test file, which isn't loaded.
+func onlyTests() {}
+
+// onlyIgnored is used by a file excluded by a build constraint.
+func onlyIgnored() {}
@@ -0,0 +1,5 @@
+package fix
+
+import "testing"
+
+func TestOnlyTests(t *testing.T) { onlyTests() }
@@ -0,0 +1,13 @@
+package launch
+
+func work() {}
+
+func cleanup() {}
+
+func run(f func()) { f() }
+
+// work and cleanup are only referenced as arguments of go and defer.
+func Start() {
+	go run(work)
+	defer run(cleanup)
+}
@@ -0,0 +1,28 @@
+package panics
+
+var handlers []func()
+
+func register(f func()) { handlers = append(handlers, f) }
+
+func boom() { panic("boom") }
+
+// Calls may panic through boom.
+func Calls() { boom() }
+
+// Registers only passes boom as a value, so it can't panic.
+func Registers() { register(boom) }
+
+// Setup only registers a closure which panics, so it can't panic.
+func Setup() { register(func() { panic("later") }) }
+
+// Top only calls Setup, so it can't panic either.
+func Top() { Setup() }
+
+// Later registers a closure which calls boom, so it can't panic.
+func Later() { register(func() { boom() }) }
+
+// Now calls a closure which calls boom, so it may panic.
+func Now() { func() { boom() }() }
+
+// Deferred defers a closure which panics, so it may panic.
+func Deferred() { defer func() { panic("deferred") }() }
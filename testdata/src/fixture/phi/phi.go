@@ -0,0 +1,13 @@
+package phi
+
+func next(x, v int) int { return x + v }
+
+// The call to next is first reached through a phi in the range loop's
+// header, which precedes the loop body.
+func Sum(s []int) int {
+	x := 0
+	for _, v := range s {
+		x = next(x, v)
+	}
+	return x
+}
@@ -0,0 +1,9 @@
+package app
+
+import "fixture/policy/lib"
+
+// Run uses both banned Fatal and restricted Query.
+func Run() int {
+	lib.Fatal()
+	return lib.Query()
+}
@@ -0,0 +1,6 @@
+package cmd
+
+import "fixture/policy/lib"
+
+// Run may use Fatal.
+func Run() { lib.Fatal() }
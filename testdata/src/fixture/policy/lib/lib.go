@@ -0,0 +1,13 @@
+package lib
+
+var state int
+
+func init() { state = 1 }
+
+// Fatal is banned except from cmd.
+func Fatal() {}
+
+// Query is restricted to service, but may be used within lib.
+func Query() int { return helper() }
+
+func helper() int { return state }
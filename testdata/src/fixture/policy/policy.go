@@ -0,0 +1,13 @@
+package policy
+
+import (
+	"fixture/policy/app"
+	"fixture/policy/cmd"
+	"fixture/policy/service"
+)
+
+func Run() {
+	app.Run()
+	cmd.Run()
+	service.Run()
+}
@@ -0,0 +1,6 @@
+package service
+
+import "fixture/policy/lib"
+
+// Run may use Query.
+func Run() int { return lib.Query() }
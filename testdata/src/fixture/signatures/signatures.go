@@ -0,0 +1,35 @@
+package signatures
+
+type Namer interface {
+	Name(prefix string) string
+}
+
+type T struct{}
+
+// Name may be called through Namer, so its result may be used and its
+// parameter can't be removed.
+func (T) Name(prefix string) string { return "" }
+
+func greet(s string) string { return s }
+
+func same(n int) int { return n }
+
+// unread never reads its parameter.
+func unread(n int) {}
+
+// handler's signature is fixed by the func type it's passed as.
+func handler(n int) {}
+
+func handle(f func(int)) { f(0) }
+
+func Run() {
+	var t T
+	t.Name("x")
+	// long string constants with a common prefix are different constants
+	greet("a rather long string constant, the first")
+	greet("a rather long string constant, the second")
+	same(1)
+	same(1)
+	unread(1)
+	handle(handler)
+}
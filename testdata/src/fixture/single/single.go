@@ -0,0 +1,20 @@
+package single
+
+type T struct{}
+
+func (T) m() int { return 1 }
+
+func helper() int { return 2 }
+
+func callback() int { return 3 }
+
+func apply(f func() int) int { return f() }
+
+// Run calls helper once and only passes callback as a value.
+func Run() int { return helper() + apply(callback) }
+
+// Get only uses m as a method value.
+func Get() func() int {
+	var t T
+	return t.m
+}
@@ -0,0 +1,9 @@
+package unexport
+
+func Run() { Internal(); Tested() }
+
+// Internal is only used within the package, so it's unexported.
+func Internal() {}
+
+// Tested is also used by an external test package, so it's kept.
+func Tested() {}
@@ -0,0 +1,9 @@
+package unexport_test
+
+import (
+	"testing"
+
+	"fixture/unexport"
+)
+
+func TestTested(t *testing.T) { unexport.Tested() }
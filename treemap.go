@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// The size of the treemap in pixels.
+const (
+	treemapWidth  = 1200
+	treemapHeight = 800
+
+	// the height of a package's label
+	treemapLabel = 14
+)
+
+type rect struct {
+	x, y, w, h float64
+}
+
+// A node of the treemap with its size and the rectangle it was laid out in.
+type treemapNode struct {
+	name  string
+	size  float64
+	count int
+	root  bool
+	rect  rect
+
+	children []*treemapNode
+}
+
+type bySizeDesc []*treemapNode
+
+func (a bySizeDesc) Len() int      { return len(a) }
+func (a bySizeDesc) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a bySizeDesc) Less(i, j int) bool {
+	if a[i].size != a[j].size {
+		return a[i].size > a[j].size
+	}
+	return a[i].name < a[j].name
+}
+
+// The worst aspect ratio of a row of sizes laid along a side of length w.
+func worstRatio(row []float64, w float64) float64 {
+	sum, min, max := 0.0, math.Inf(1), 0.0
+	for _, s := range row {
+		sum += s
+		min = math.Min(min, s)
+		max = math.Max(max, s)
+	}
+	return math.Max(w*w*max/(sum*sum), sum*sum/(w*w*min))
+}
+
+// Lay out nodes, which must be sorted largest first, within a rectangle
+// using the squarified treemap algorithm: nodes are added to a row along the
+// shorter side for as long as that improves the row's worst aspect ratio.
+func squarify(nodes []*treemapNode, r rect) {
+	total := 0.0
+	for _, n := range nodes {
+		total += n.size
+	}
+	if total <= 0 || r.w <= 0 || r.h <= 0 {
+		return
+	}
+	scale := r.w * r.h / total
+	for len(nodes) > 0 {
+		side := math.Min(r.w, r.h)
+		row := []float64{nodes[0].size * scale}
+		i := 1
+		for ; i < len(nodes); i++ {
+			next := append(row[:len(row):len(row)], nodes[i].size*scale)
+			if worstRatio(next, side) > worstRatio(row, side) {
+				break
+			}
+			row = next
+		}
+
+		sum := 0.0
+		for _, s := range row {
+			sum += s
+		}
+		thickness := sum / side
+		offset := 0.0
+		for j, s := range row {
+			length := s / thickness
+			if r.w >= r.h {
+				nodes[j].rect = rect{r.x, r.y + offset, thickness, length}
+			} else {
+				nodes[j].rect = rect{r.x + offset, r.y, length, thickness}
+			}
+			offset += length
+		}
+		if r.w >= r.h {
+			r = rect{r.x + thickness, r.y, r.w - thickness, r.h}
+		} else {
+			r = rect{r.x, r.y + thickness, r.w, r.h - thickness}
+		}
+		nodes = nodes[i:]
+	}
+}
+
+// The colour of a function: red if unused, grey for unused roots, otherwise
+// from yellow to green as the usage count grows relative to the maximum.
+func treemapColor(n *treemapNode, max int) string {
+	if n.count == 0 {
+		if n.root {
+			return "#bbbbbb"
+		}
+		return "#d73027"
+	}
+	t := 1.0
+	if max > 1 {
+		t = math.Log(float64(n.count)) / math.Log(float64(max))
+	}
+	lerp := func(a, b int) int { return a + int(t*float64(b-a)) }
+	return fmt.Sprintf("#%02x%02x%02x", lerp(0xfe, 0x1a), lerp(0xe0, 0x98), lerp(0x8b, 0x50))
+}
+
+// Print an SVG treemap of packages and their functions. A function's area is
+// its number of SSA instructions and its colour its usage count.
+func printTreemap(calls map[string]int, fnNames map[string]*ssa.Function, shouldPrint func(name string) bool) {
+	pkgs := map[string]*treemapNode{}
+	max := 0
+	for name, n := range calls {
+		if strings.Contains(name, "$") || !shouldPrint(name) {
+			continue
+		}
+		fn := fnNames[name]
+		path := funcPackage(fn)
+		pkg, ok := pkgs[path]
+		if !ok {
+			pkg = &treemapNode{name: path}
+			pkgs[path] = pkg
+		}
+		// functions without a body still get a sliver of area
+		size := float64(instrCount(fn))
+		if size == 0 {
+			size = 1
+		}
+		pkg.children = append(pkg.children, &treemapNode{name: name, size: size, count: n, root: isRoot(fn)})
+		pkg.size += size
+		if n > max {
+			max = n
+		}
+	}
+
+	nodes := []*treemapNode{}
+	for _, pkg := range pkgs {
+		nodes = append(nodes, pkg)
+	}
+	sort.Sort(bySizeDesc(nodes))
+	squarify(nodes, rect{0, 0, treemapWidth, treemapHeight})
+
+	fmt.Printf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"sans-serif\" font-size=\"11\">\n", treemapWidth, treemapHeight)
+	for _, pkg := range nodes {
+		r := pkg.rect
+		fmt.Printf("<g>\n<title>%s</title>\n", html.EscapeString(pkg.name))
+		fmt.Printf("<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"#444444\"/>\n", r.x, r.y, r.w, r.h)
+		inner := rect{r.x + 1, r.y + 1, r.w - 2, r.h - 2}
+		if inner.h > 3*treemapLabel {
+			fmt.Printf("<text x=\"%.1f\" y=\"%.1f\" fill=\"#ffffff\">%s</text>\n", r.x+3, r.y+treemapLabel-3, html.EscapeString(pkg.name))
+			inner.y += treemapLabel
+			inner.h -= treemapLabel
+		}
+		sort.Sort(bySizeDesc(pkg.children))
+		squarify(pkg.children, inner)
+		for _, fn := range pkg.children {
+			r := fn.rect
+			fmt.Printf("<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\" stroke=\"#ffffff\" stroke-width=\"0.5\"><title>%s: %d usages, %d instructions</title></rect>\n",
+				r.x, r.y, r.w, r.h, treemapColor(fn, max), html.EscapeString(fn.name), fn.count, int(fn.size))
+			// label functions whose rectangle fits their name, roughly
+			label := strings.TrimPrefix(fn.name, pkg.name+".")
+			if r.h > treemapLabel && r.w > float64(7*len(label)) {
+				fmt.Printf("<text x=\"%.1f\" y=\"%.1f\">%s</text>\n", r.x+3, r.y+treemapLabel-3, html.EscapeString(label))
+			}
+		}
+		fmt.Println("</g>")
+	}
+	fmt.Println("</svg>")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// The unexported form of a name. A leading run of capitals is lowered as a
+// whole, keeping the last capital if it starts the next word, so "HTTPGet"
+// becomes "httpGet" and "URL" becomes "url".
+func unexportedName(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// Report why an object can't be renamed, if it can't. The new name must not
+// be a keyword or predeclared identifier, must not collide with another
+// package level name, import or method, and must not be declared locally
+// anywhere in the package where it could shadow the renamed object.
+func renameConflict(info *loader.PackageInfo, obj *types.Func, newName string) string {
+	if token.Lookup(newName).IsKeyword() {
+		return newName + " is a keyword"
+	}
+	if types.Universe.Lookup(newName) != nil {
+		return newName + " is predeclared"
+	}
+
+	sig := obj.Type().(*types.Signature)
+	if recv := sig.Recv(); recv != nil {
+		if other, _, _ := types.LookupFieldOrMethod(recv.Type(), true, info.Pkg, newName); other != nil {
+			return "the receiver already has a field or method " + newName
+		}
+		return ""
+	}
+
+	if info.Pkg.Scope().Lookup(newName) != nil {
+		return "the package already declares " + newName
+	}
+	for _, file := range info.Files {
+		if scope := info.Scopes[file]; scope != nil && scope.Lookup(newName) != nil {
+			return "a file imports a package as " + newName
+		}
+	}
+	for id, def := range info.Defs {
+		if id.Name == newName && def != nil && def.Parent() != info.Pkg.Scope() {
+			return newName + " is declared locally and could shadow it"
+		}
+	}
+	return ""
+}
+
+// Rename the declaration and every use of an object in a package, returning
+// the files which were modified.
+func renameObject(info *loader.PackageInfo, obj types.Object, newName string) map[*ast.File]bool {
+	idents := map[*ast.Ident]bool{}
+	for id, def := range info.Defs {
+		if def == obj {
+			idents[id] = true
+		}
+	}
+	for id, use := range info.Uses {
+		if use == obj {
+			idents[id] = true
+		}
+	}
+
+	modified := map[*ast.File]bool{}
+	for _, file := range info.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && idents[id] {
+				id.Name = newName
+				modified[file] = true
+			}
+			return true
+		})
+	}
+	return modified
+}
+
+// Rename exported functions and methods which are only used from within
+// their own package to their unexported form, returning the modified files.
+// Packages whose exported API is public, and functions whose new name would
+// conflict, are skipped.
+func unexportFunctions(program *loader.Program, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool, publicMatcher *regexp.Regexp, dryRun bool) map[*ast.File]*loader.PackageInfo {
+	ifaceMethods := interfaceMethods(program)
+
+	// names used by test files and files for other platforms, anywhere in
+	// the loaded packages, which won't be renamed
+	unloaded := map[string]bool{}
+	for _, idents := range unloadedIdents(program) {
+		for ident := range idents {
+			unloaded[ident] = true
+		}
+	}
+
+	names := []string{}
+	for name := range fnNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// names given to renamed functions, which aren't in the package scope
+	taken := map[*types.Package]map[string]bool{}
+
+	modified := map[*ast.File]*loader.PackageInfo{}
+	for _, name := range names {
+		fn := fnNames[name]
+		if strings.Contains(name, "$") || !shouldPrint(name) || fn.Pkg == nil || fn.Synthetic != "" {
+			continue
+		}
+		obj, ok := fn.Object().(*types.Func)
+		if !ok || !obj.Exported() || len(sites[name]) == 0 {
+			continue
+		}
+		path := fn.Pkg.Object.Path()
+		// uses are only seen from packages which were walked
+		if !usagesMatcher.MatchString(path) {
+			continue
+		}
+		if publicMatcher != nil && publicMatcher.MatchString(path) {
+			continue
+		}
+		if fn.Signature.Recv() != nil && ifaceMethods[fn.Name()] {
+			continue
+		}
+
+		external := false
+		for _, s := range sites[name] {
+			external = external || sitePackage(s) != path
+		}
+		if external {
+			continue
+		}
+		if unloaded[fn.Name()] {
+			fmt.Fprintf(os.Stderr, "skipping %s: used by a test file or a file excluded by build constraints\n", name)
+			continue
+		}
+
+		info := program.AllPackages[fn.Pkg.Object]
+		newName := unexportedName(obj.Name())
+		reason := renameConflict(info, obj, newName)
+		if reason == "" && taken[info.Pkg][newName] {
+			reason = "another function is being renamed to " + newName
+		}
+		if reason != "" {
+			fmt.Fprintf(os.Stderr, "skipping %s: %s\n", name, reason)
+			continue
+		}
+		if taken[info.Pkg] == nil {
+			taken[info.Pkg] = map[string]bool{}
+		}
+		taken[info.Pkg][newName] = true
+		if !dryRun {
+			fmt.Printf("renaming %s to %s\n", name, newName)
+		}
+		for file := range renameObject(info, obj, newName) {
+			modified[file] = info
+		}
+	}
+	return modified
+}
+
+// Unexport functions only used from within their own package, rewriting
+// the source or printing a diff.
+func unexportUnused(program *loader.Program, fnNames map[string]*ssa.Function, sites map[string][]site, shouldPrint func(name string) bool, publicMatcher *regexp.Regexp, dryRun bool) {
+	modified := unexportFunctions(program, fnNames, sites, shouldPrint, publicMatcher, dryRun)
+	writeFiles(program.Fset, modified, dryRun)
+}
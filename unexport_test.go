@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"testing"
+)
+
+func TestUnexportUnused(t *testing.T) {
+	program, a := loadFixture(t, "fixture/unexport")
+	usagesMatcher = fixtureMatcher("fixture/unexport")
+	defer func() { usagesMatcher = nil }()
+	all := func(name string) bool { return true }
+
+	modified := unexportFunctions(program, a.fnNames, a.v.sites, all, nil, true)
+	if len(modified) != 1 {
+		t.Fatalf("expected 1 modified file, got %d", len(modified))
+	}
+	for file := range modified {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, program.Fset, file); err != nil {
+			t.Fatal(err)
+		}
+		src := buf.String()
+		for _, want := range []string{"func internal()", "func Tested()", "internal(); Tested()"} {
+			if !strings.Contains(src, want) {
+				t.Errorf("expected %q in:\n%s", want, src)
+			}
+		}
+	}
+}
@@ -10,9 +10,80 @@ import (
 type visitor struct {
 	calls map[string]int
 
+	// every site a function was referenced from, keyed by function name
+	sites map[string][]site
+
+	// sites of standard library functions, which are tracked separately
+	// from sites unless -std is set
+	stdSites map[string][]site
+
+	// every site a package level variable was referenced from
+	globals map[string][]site
+
+	// every call, go and defer instruction walked, whatever the callee
+	callInstrs []ssa.CallInstruction
+
 	// a map of ssa.Instructions and ssa.Values which have
 	// already been visited
 	visited map[interface{}]bool
+
+	// the instruction currently being walked
+	instr ssa.Instruction
+
+	// loop nesting depths of basic blocks, computed lazily per function
+	depths map[*ssa.Function]map[*ssa.BasicBlock]int
+}
+
+// a site is a single reference to a function.
+type site struct {
+	instr ssa.Instruction
+
+	// the function or package level variable referenced
+	target ssa.Value
+
+	// the named function containing the referencing instruction
+	caller *ssa.Function
+
+	// the loop nesting depth of the referencing instruction
+	depth int
+}
+
+func newVisitor(calls map[string]int) *visitor {
+	return &visitor{
+		calls:    calls,
+		sites:    make(map[string][]site),
+		stdSites: make(map[string][]site),
+		globals:  make(map[string][]site),
+		visited:  make(map[interface{}]bool),
+		depths:   make(map[*ssa.Function]map[*ssa.BasicBlock]int),
+	}
+}
+
+// a site referencing target from the instruction currently being walked.
+func (v *visitor) newSite(target ssa.Value) site {
+	return site{v.instr, target, enclosing(v.instr.Parent()), v.loopDepth(v.instr)}
+}
+
+// the outermost function enclosing an anonymous function.
+func enclosing(fn *ssa.Function) *ssa.Function {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	return fn
+}
+
+// the loop nesting depth of an instruction within its function.
+func (v *visitor) loopDepth(ins ssa.Instruction) int {
+	if ins == nil || ins.Block() == nil {
+		return 0
+	}
+	fn := ins.Parent()
+	depths, ok := v.depths[fn]
+	if !ok {
+		depths = loopDepths(fn)
+		v.depths[fn] = depths
+	}
+	return depths[ins.Block()]
 }
 
 func (v *visitor) VisitInstr(ins ssa.Instruction) *visitor {
@@ -22,10 +93,11 @@ func (v *visitor) VisitInstr(ins ssa.Instruction) *visitor {
 func (v *visitor) VisitValue(val ssa.Value) *visitor {
 	if fn, ok := val.(*ssa.Function); ok {
 
+		rel := funcName(fn)
 		if !includeStdPkgs && inStandardPackages(fn) {
+			v.stdSites[rel] = append(v.stdSites[rel], v.newSite(fn))
 			return nil
 		}
-		rel := funcName(fn)
 
 		// '$' indicates special functions such as 'main()'
 		if strings.Contains(rel, "$") {
@@ -39,6 +111,12 @@ func (v *visitor) VisitValue(val ssa.Value) *visitor {
 			panic("unexpected function visited " + rel)
 		}
 		v.calls[rel]++
+		v.sites[rel] = append(v.sites[rel], v.newSite(fn))
+		return nil
+	}
+	if g, ok := val.(*ssa.Global); ok {
+		rel := g.RelString(nil)
+		v.globals[rel] = append(v.globals[rel], v.newSite(g))
 		return nil
 	}
 	return v
@@ -55,6 +133,12 @@ func (v *visitor) walkInstr(ins ssa.Instruction) {
 	if v == nil {
 		return
 	}
+	prev := v.instr
+	v.instr = ins
+	defer func() { v.instr = prev }()
+	if call, ok := ins.(ssa.CallInstruction); ok {
+		v.callInstrs = append(v.callInstrs, call)
+	}
 	switch x := ins.(type) {
 	case *ssa.BinOp:
 		v.walkValue(x.X)
@@ -78,6 +162,9 @@ func (v *visitor) walkInstr(ins ssa.Instruction) {
 		v.walkValue(x.X)
 	case *ssa.Defer:
 		v.walkValue(x.Call.Value)
+		for i := range x.Call.Args {
+			v.walkValue(x.Call.Args[i])
+		}
 	case *ssa.Extract:
 		v.walkValue(x.Tuple)
 	case *ssa.Field:
@@ -86,6 +173,9 @@ func (v *visitor) walkInstr(ins ssa.Instruction) {
 		v.walkValue(x.X)
 	case *ssa.Go:
 		v.walkValue(x.Call.Value)
+		for i := range x.Call.Args {
+			v.walkValue(x.Call.Args[i])
+		}
 	case *ssa.If:
 		v.walkValue(x.Cond)
 	case *ssa.Index:
@@ -155,6 +245,12 @@ func (v *visitor) walkValue(val ssa.Value) {
 	if val == nil || v == nil || v.visited[val] {
 		return
 	}
+	// a value which is also an instruction, such as a call reached through
+	// a phi, is walked as the instruction so its sites are attributed to it
+	if ins, ok := val.(ssa.Instruction); ok {
+		v.walkInstr(ins)
+		return
+	}
 	v = v.VisitValue(val)
 	if v == nil {
 		return
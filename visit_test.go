@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+func TestSiteInstruction(t *testing.T) {
+	_, a := loadFixture(t, "fixture/phi")
+	sites := a.v.sites["fixture/phi.next"]
+	if len(sites) != 1 {
+		t.Fatalf("expected 1 site, got %d", len(sites))
+	}
+	call, ok := sites[0].instr.(*ssa.Call)
+	if !ok {
+		t.Fatalf("site attributed to %T, not the call", sites[0].instr)
+	}
+	if sites[0].depth != 1 {
+		t.Errorf("expected a loop depth of 1, got %d", sites[0].depth)
+	}
+	found := false
+	for _, c := range a.v.callInstrs {
+		found = found || c == ssa.CallInstruction(call)
+	}
+	if !found {
+		t.Error("call missing from callInstrs")
+	}
+}
+
+func TestGoDeferArgs(t *testing.T) {
+	_, a := loadFixture(t, "fixture/launch")
+	for _, name := range []string{"fixture/launch.work", "fixture/launch.cleanup"} {
+		if n := a.calls[name]; n != 1 {
+			t.Errorf("expected 1 usage of %s, got %d", name, n)
+		}
+	}
+}